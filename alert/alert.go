@@ -0,0 +1,91 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/masone/ogn/config"
+	"log"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+type Alerter interface {
+	Alert(subject string, message string) error
+}
+
+type LogAlerter struct{}
+
+func (LogAlerter) Alert(subject string, message string) error {
+	log.Printf("ALERT %s: %s\n", subject, message)
+	return nil
+}
+
+// WebhookAlerter posts {"subject": ..., "message": ...} to URL.
+type WebhookAlerter struct {
+	URL string
+}
+
+func (a WebhookAlerter) Alert(subject string, message string) error {
+	body, err := json.Marshal(map[string]string{"subject": subject, "message": message})
+	if err != nil {
+		return err
+	}
+
+	client := http.Client{Timeout: 10 * time.Second}
+	response, err := client.Post(a.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("alert: webhook responded %s", response.Status)
+	}
+	return nil
+}
+
+// EmailAlerter sends mails through an SMTP server without authentication, eg. a local relay.
+type EmailAlerter struct {
+	Server string
+	From   string
+	To     []string
+}
+
+func (a EmailAlerter) Alert(subject string, message string) error {
+	mail := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s\r\n",
+		a.From, strings.Join(a.To, ", "), subject, message)
+	return smtp.SendMail(a.Server, nil, a.From, a.To, []byte(mail))
+}
+
+// Multi sends alerts to all alerters, logging failures.
+type Multi []Alerter
+
+func (m Multi) Alert(subject string, message string) error {
+	for _, a := range m {
+		if err := a.Alert(subject, message); err != nil {
+			log.Println(err)
+		}
+	}
+	return nil
+}
+
+// FromEnv always logs alerts. ALERT_WEBHOOK and ALERT_SMTP/ALERT_EMAIL_FROM/ALERT_EMAIL_TO add more channels.
+func FromEnv() Alerter {
+	m := Multi{LogAlerter{}}
+
+	if url := os.Getenv("ALERT_WEBHOOK"); url != "" {
+		m = append(m, WebhookAlerter{URL: url})
+	}
+	if server := os.Getenv("ALERT_SMTP"); server != "" {
+		m = append(m, EmailAlerter{
+			Server: server,
+			From:   os.Getenv("ALERT_EMAIL_FROM"),
+			To:     config.List(os.Getenv("ALERT_EMAIL_TO")),
+		})
+	}
+	return m
+}
@@ -0,0 +1,55 @@
+package alert
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestWebhookAlerter(t *testing.T) {
+	var got map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer server.Close()
+
+	if err := (WebhookAlerter{URL: server.URL}).Alert("Receiver LSPH recovered", "Last beacon: never"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"subject": "Receiver LSPH recovered", "message": "Last beacon: never"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	if err := (WebhookAlerter{URL: failing.URL}).Alert("subject", "message"); err == nil {
+		t.Error("want an error")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	for key, v := range map[string]string{
+		"ALERT_WEBHOOK":    "",
+		"ALERT_SMTP":       "localhost:25",
+		"ALERT_EMAIL_FROM": "ogn@example.com",
+		"ALERT_EMAIL_TO":   "tower@example.com, chief@example.com,",
+	} {
+		defer os.Setenv(key, os.Getenv(key))
+		os.Setenv(key, v)
+	}
+
+	m := FromEnv().(Multi)
+	if len(m) != 2 {
+		t.Fatalf("got %d alerters, want 2", len(m))
+	}
+	email := m[1].(EmailAlerter)
+	if want := []string{"tower@example.com", "chief@example.com"}; !reflect.DeepEqual(email.To, want) {
+		t.Errorf("got %q, want %q", email.To, want)
+	}
+}
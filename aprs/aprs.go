@@ -1,98 +1,76 @@
 package aprs
 
 import (
-	"bufio"
-	"fmt"
-	"github.com/martinhpedersen/libfap-go"
+	"context"
+	"github.com/masone/ogn/packet"
 	"io"
 	"log"
-	"net"
-	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
-func Listen(processor func(packet *fap.Packet)) {
-	defer fap.Cleanup()
-	var reader *bufio.Reader
+// Handler processes a beacon. Beacons it returns an error for are quarantined.
+type Handler func(p *packet.Packet) error
 
-	if len(os.Args) > 1 {
-		reader = file_reader(os.Args[1])
-	} else {
-		reader = aprs_reader()
-	}
-
-	each_message(reader, processor)
+// Sources replaying recorded lines tell which time a line was received at.
+type timedSource interface {
+	Now() time.Time
 }
 
-func connect() net.Conn {
-	connection, err := net.Dial("tcp", "aprs.glidernet.org:14580")
-	if err != nil {
-		panic(err)
-	} else {
-		return connection
-	}
-}
-
-func authenticate(c net.Conn) {
-	auth := fmt.Sprintf("user %s pass -1 vers libfapGo 0.0.1 filter r/%s/%s/%s\n",
-		os.Getenv("APRS_USER"),
-		os.Getenv("AF_LAT"),
-		os.Getenv("AF_LNG"),
-		os.Getenv("APRS_RADIUS"),
-	)
-	fmt.Fprintf(c, auth)
-}
-
-func keepalive(c net.Conn) {
-	ticker := time.NewTicker(30 * time.Second)
+// Listen reads lines from the source until it is exhausted or the context is cancelled.
+func Listen(ctx context.Context, source Source, handler Handler) error {
+	defer source.Close()
 
+	done := make(chan struct{})
+	defer close(done)
 	go func() {
-		for t := range ticker.C {
-			fmt.Fprintf(c, "# libfapGo keepalive %s\n", t)
+		select {
+		case <-ctx.Done():
+			// unblocks a pending ReadLine
+			source.Close()
+		case <-done:
 		}
 	}()
-}
 
-func each_message(r *bufio.Reader, processor func(packet *fap.Packet)) {
+	now := time.Now
+	if ts, ok := source.(timedSource); ok {
+		now = ts.Now
+	}
+
 	for {
-		line, err := r.ReadString('\n')
+		line, err := source.ReadLine()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if line != "" {
+			each_message(line, now(), handler)
+		}
 		if err == io.EOF {
 			log.Println(err)
-			return
+			log.Println(CurrentStats())
+			return nil
 		} else if err != nil {
-			panic(err)
-		}
-
-		// APRS,qAS: aircraft beacon
-		// APRS,TCPIP*,qAC: ground station beacon
-		if strings.Contains(line, ">APRS,qAS") {
-			packet, err := fap.ParseAprs(line, false)
-			if err == nil {
-				processor(packet)
-			} else {
-				log.Println(line)
-				log.Fatal(err)
-			}
+			return err
 		}
 	}
 }
 
-func aprs_reader() *bufio.Reader {
-	connection := connect()
-	authenticate(connection)
-	keepalive(connection)
+func each_message(line string, now time.Time, handler Handler) {
+	atomic.AddUint64(&stats.Lines, 1)
 
-	return bufio.NewReader(connection)
-}
-
-func file_reader(fn string) *bufio.Reader {
-	fmt.Printf("Reading from %s\n", fn)
-
-	f, err := os.Open(fn)
-	if err != nil {
-		panic(err)
+	// server comments
+	if strings.HasPrefix(line, "#") {
+		return
 	}
 
-	return bufio.NewReader(f)
+	p, err := packet.ParseAt(line, now)
+	if err == nil {
+		err = handler(p)
+	}
+	if err == nil {
+		atomic.AddUint64(&stats.Beacons, 1)
+	} else {
+		reject(line, err)
+	}
 }
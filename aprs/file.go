@@ -0,0 +1,138 @@
+package aprs
+
+import (
+	"bufio"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// NewFileSource reads plain, gzip (.gz) and bzip2 (.bz2) compressed logfiles.
+func NewFileSource(fn string) (*ReaderSource, error) {
+	fmt.Printf("Reading from %s\n", fn)
+
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	var r io.Reader = f
+	var c io.Closer = f
+	switch {
+	case strings.HasSuffix(fn, ".gz"):
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		r = gz
+		c = closers{gz, f}
+	case strings.HasSuffix(fn, ".bz2"):
+		r = bzip2.NewReader(f)
+	}
+
+	return &ReaderSource{reader: bufio.NewReader(r), closer: c}, nil
+}
+
+// closers closes a decompressor and its file
+type closers []io.Closer
+
+func (cs closers) Close() error {
+	var err error
+	for _, c := range cs {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// FilesSource reads several logfiles one after the other. OnOpen is called
+// with the name of each file before its first line is read.
+type FilesSource struct {
+	Files  []string
+	OnOpen func(fn string)
+
+	current *ReaderSource
+	mutex   sync.Mutex
+}
+
+// NewFilesSource expands the glob patterns and orders the files chronologically
+// by the date in their names (ogn.2015-08-28.log), falling back to the name.
+func NewFilesSource(patterns ...string) (*FilesSource, error) {
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("aprs: no files found for %s", pattern)
+		}
+		files = append(files, matches...)
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		di, iok := DateFromFilename(files[i])
+		dj, jok := DateFromFilename(files[j])
+		if iok && jok && !di.Equal(dj) {
+			return di.Before(dj)
+		}
+		return files[i] < files[j]
+	})
+
+	return &FilesSource{Files: files}, nil
+}
+
+func (s *FilesSource) ReadLine() (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for {
+		if s.current == nil {
+			if len(s.Files) == 0 {
+				return "", io.EOF
+			}
+
+			f, err := NewFileSource(s.Files[0])
+			if err != nil {
+				return "", err
+			}
+			if s.OnOpen != nil {
+				s.OnOpen(s.Files[0])
+			}
+			s.current = f
+			s.Files = s.Files[1:]
+		}
+
+		line, err := s.current.ReadLine()
+		if err != io.EOF {
+			return line, err
+		}
+
+		s.current.Close()
+		s.current = nil
+		if line != "" {
+			return line, nil
+		}
+	}
+}
+
+func (s *FilesSource) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.Files = nil
+	if s.current == nil {
+		return nil
+	}
+	err := s.current.Close()
+	s.current = nil
+	return err
+}
@@ -0,0 +1,96 @@
+package aprs
+
+import (
+	"compress/gzip"
+	"github.com/masone/ogn/clock"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeGzip(t *testing.T, fn string, lines ...string) {
+	f, err := os.Create(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	z := gzip.NewWriter(f)
+	for _, line := range lines {
+		z.Write([]byte(line + "\n"))
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// The beacons of each file are dated by the file name, despite the gap between them.
+func TestReplayFilesDates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeGzip(t, filepath.Join(dir, "ogn.2015-08-28.log.gz"), "FLRDDA5BA>APRS,qAS,LSPH:/080000h4415.41N/00600.03E'342/049/A=005524")
+	writeGzip(t, filepath.Join(dir, "ogn.2015-08-30.log.gz"), "FLRDDA5BA>APRS,qAS,LSPH:/090000h4415.41N/00600.03E'342/049/A=005524")
+
+	files, err := NewFilesSource(filepath.Join(dir, "*.log.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay := Replay(files, 0, clock.NewSimulated(time.Time{}))
+	files.OnOpen = func(fn string) {
+		if d, ok := DateFromFilename(fn); ok {
+			replay.SetDate(d, false)
+		}
+	}
+
+	want := []time.Time{
+		time.Date(2015, 8, 28, 8, 0, 0, 0, time.UTC),
+		time.Date(2015, 8, 30, 9, 0, 0, 0, time.UTC),
+	}
+	for _, w := range want {
+		if _, err := replay.ReadLine(); err != nil {
+			t.Fatal(err)
+		}
+		if !replay.Now().Equal(w) {
+			t.Errorf("got %s, want %s", replay.Now(), w)
+		}
+	}
+	if _, err := replay.ReadLine(); err != io.EOF {
+		t.Errorf("got %v, want EOF", err)
+	}
+}
+
+// Listen closes the source from another goroutine when its context is cancelled.
+func TestFilesSourceCloseWhileReading(t *testing.T) {
+	dir, err := ioutil.TempDir("", "files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, day := range []string{"2015-08-28", "2015-08-29", "2015-08-30"} {
+		writeGzip(t, filepath.Join(dir, "ogn."+day+".log.gz"), "FLRDDA5BA>APRS,qAS,LSPH:/080000h4415.41N/00600.03E'342/049/A=005524")
+	}
+	files, err := NewFilesSource(filepath.Join(dir, "*.log.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if _, err := files.ReadLine(); err != nil {
+				return
+			}
+		}
+	}()
+	files.Close()
+	<-done
+}
@@ -0,0 +1,85 @@
+package aprs
+
+import (
+	"fmt"
+	"github.com/masone/ogn/packet"
+	"strings"
+)
+
+type BoundingBox struct {
+	MinLat, MinLon, MaxLat, MaxLon float64
+}
+
+// ParseBoundingBox parses minLat,minLon,maxLat,maxLon.
+func ParseBoundingBox(s string) (*BoundingBox, error) {
+	var b BoundingBox
+	_, err := fmt.Sscanf(s, "%f,%f,%f,%f", &b.MinLat, &b.MinLon, &b.MaxLat, &b.MaxLon)
+	if err != nil {
+		return nil, fmt.Errorf("aprs: invalid bounding box %q", s)
+	}
+	return &b, nil
+}
+
+func (b *BoundingBox) Contains(lat float64, lon float64) bool {
+	return lat >= b.MinLat && lat <= b.MaxLat && lon >= b.MinLon && lon <= b.MaxLon
+}
+
+// FilterSource drops beacons not relayed by one of the Receivers or
+// positioned outside of Box. Empty criteria match everything. Server
+// comment lines and unparseable lines are always kept, the latter end
+// up in the quarantine.
+type FilterSource struct {
+	Source
+	Receivers []string
+	Box       *BoundingBox
+}
+
+func Filter(source Source, receivers []string, box *BoundingBox) *FilterSource {
+	return &FilterSource{Source: source, Receivers: receivers, Box: box}
+}
+
+func (s *FilterSource) ReadLine() (string, error) {
+	for {
+		line, err := s.Source.ReadLine()
+		if line == "" || s.match(line) {
+			return line, err
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+func (s *FilterSource) match(line string) bool {
+	if strings.HasPrefix(line, "#") {
+		return true
+	}
+
+	p, err := packet.Parse(line)
+	if err != nil {
+		return true
+	}
+
+	if len(s.Receivers) > 0 && !s.relayedBy(p) {
+		return false
+	}
+	if s.Box != nil && !s.Box.Contains(p.Latitude, p.Longitude) {
+		return false
+	}
+	return true
+}
+
+// qAS,LSPH: relayed by receiver LSPH, or sent by the receiver itself
+func (s *FilterSource) relayedBy(p *packet.Packet) bool {
+	var receiver string
+	if len(p.Path) > 0 {
+		receiver = p.Path[len(p.Path)-1]
+	}
+
+	for _, r := range s.Receivers {
+		if strings.EqualFold(r, receiver) || strings.EqualFold(r, p.SrcCallsign) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package aprs
+
+import (
+	"io"
+	"testing"
+)
+
+func TestFilterReceivers(t *testing.T) {
+	lines := []string{
+		"# aprsc 2.1.4 28 Aug 2015 16:58:00 GMT GLIDERN1 1.2.3.4:14580",
+		"FLRDDA5BA>APRS,qAS,LSPH:/165829h4415.41N/00600.03E'342/049/A=005524",
+		"FLRDDA5BB>APRS,qAS,LSZF:/165829h4415.41N/00600.03E'342/049/A=005524",
+		"LSPH>OGNSDR,TCPIP*,qAC,GLIDERN2:/132201h4657.02NI00722.57E&/A=001690",
+		"garbage",
+	}
+	source := Filter(NewSliceSource(lines), []string{"lsph"}, nil)
+
+	var got []string
+	for {
+		line, err := source.ReadLine()
+		if err == io.EOF {
+			break
+		}
+		got = append(got, line)
+	}
+
+	want := []string{lines[0], lines[1], lines[3], lines[4]}
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %q, want %q", got[i], want[i])
+		}
+	}
+}
+
+func TestFilterBoundingBox(t *testing.T) {
+	box, err := ParseBoundingBox("44,5,45,7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inside := "FLRDDA5BA>APRS,qAS,LSPH:/165829h4415.41N/00600.03E'342/049/A=005524"
+	outside := "FLRDDA5BB>APRS,qAS,LSPH:/165829h4615.41N/00600.03E'342/049/A=005524"
+	source := Filter(NewSliceSource([]string{outside, inside}), nil, box)
+
+	line, err := source.ReadLine()
+	if err != nil || line != inside {
+		t.Errorf("got %q %v, want %q", line, err, inside)
+	}
+}
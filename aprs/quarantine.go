@@ -0,0 +1,95 @@
+package aprs
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Stats struct {
+	Lines    uint64
+	Beacons  uint64
+	Rejected uint64
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("%d lines, %d beacons processed, %d rejected", s.Lines, s.Beacons, s.Rejected)
+}
+
+var (
+	stats      Stats
+	deadLetter *DeadLetter
+)
+
+func CurrentStats() Stats {
+	return Stats{
+		Lines:    atomic.LoadUint64(&stats.Lines),
+		Beacons:  atomic.LoadUint64(&stats.Beacons),
+		Rejected: atomic.LoadUint64(&stats.Rejected),
+	}
+}
+
+// DeadLetter appends rejected lines with the reason to a file:
+// 2015-08-28T12:00:00Z <tab> reason <tab> line
+type DeadLetter struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+func NewDeadLetter(fn string) (*DeadLetter, error) {
+	f, err := os.OpenFile(fn, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &DeadLetter{file: f}, nil
+}
+
+// NewDeadLetterFromEnv returns nil if DEAD_LETTER_FILE is not set.
+func NewDeadLetterFromEnv() (*DeadLetter, error) {
+	fn := os.Getenv("DEAD_LETTER_FILE")
+	if fn == "" {
+		return nil, nil
+	}
+	return NewDeadLetter(fn)
+}
+
+// SetDeadLetter quarantines rejected lines in d. Without, they are only logged.
+func SetDeadLetter(d *DeadLetter) {
+	deadLetter = d
+}
+
+func (d *DeadLetter) Write(line string, reason error) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	_, err := fmt.Fprintf(d.file, "%s\t%s\t%s\n",
+		time.Now().UTC().Format(time.RFC3339),
+		strings.Replace(reason.Error(), "\t", " ", -1),
+		strings.TrimRight(line, "\r\n"),
+	)
+	return err
+}
+
+func (d *DeadLetter) Close() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.file.Sync()
+	return d.file.Close()
+}
+
+func reject(line string, reason error) {
+	atomic.AddUint64(&stats.Rejected, 1)
+
+	if deadLetter == nil {
+		log.Printf("Rejected %s: %s\n", strings.TrimSpace(line), reason)
+		return
+	}
+	if err := deadLetter.Write(line, reason); err != nil {
+		log.Println(err)
+	}
+}
@@ -0,0 +1,182 @@
+package aprs
+
+import (
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+// ReconnectingSource keeps an APRS-IS connection alive. Dial and read errors
+// lead to a reconnect with exponential backoff, rotating through the servers.
+// The backoff is only reset once a connection delivered data for a while,
+// servers accepting and dropping connections right away are not hammered.
+type ReconnectingSource struct {
+	Servers    []string
+	Login      string
+	Timeout    time.Duration // see ServerSource
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	mutex   sync.Mutex
+	current *ServerSource
+	server  int
+	last    time.Time // of the last line received
+	first   time.Time // of the first line of the current connection
+	dialed  time.Time
+	backoff time.Duration
+	closed  chan struct{}
+}
+
+// connections delivering data for that long reset the backoff
+const stable_connection = 1 * time.Minute
+
+func NewReconnectingSource(servers []string, login string) *ReconnectingSource {
+	return &ReconnectingSource{
+		Servers:    servers,
+		Login:      login,
+		Timeout:    2 * time.Minute,
+		MinBackoff: 1 * time.Second,
+		MaxBackoff: 5 * time.Minute,
+		closed:     make(chan struct{}),
+	}
+}
+
+func (s *ReconnectingSource) ReadLine() (string, error) {
+	for {
+		c, err := s.connection()
+		if err != nil {
+			return "", err
+		}
+
+		line, err := c.ReadLine()
+		if err == nil {
+			s.last = time.Now()
+			if s.first.IsZero() {
+				s.first = s.last
+			}
+			return line, nil
+		}
+
+		// partial lines of a broken connection are dropped
+		s.disconnect(c, err)
+	}
+}
+
+func (s *ReconnectingSource) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	select {
+	case <-s.closed:
+		return nil
+	default:
+	}
+	close(s.closed)
+
+	if s.current != nil {
+		return s.current.Close()
+	}
+	return nil
+}
+
+func (s *ReconnectingSource) connection() (*ServerSource, error) {
+	s.mutex.Lock()
+	c := s.current
+	s.mutex.Unlock()
+	if c != nil {
+		return c, nil
+	}
+
+	if len(s.Servers) == 0 {
+		return nil, errors.New("aprs: no servers configured")
+	}
+
+	if s.backoff < s.MinBackoff {
+		s.backoff = s.MinBackoff
+	}
+	if wait := s.backoff - time.Since(s.dialed); wait > 0 {
+		select {
+		case <-s.closed:
+			return nil, io.EOF
+		case <-time.After(wait):
+		}
+	}
+
+	for {
+		select {
+		case <-s.closed:
+			return nil, io.EOF
+		default:
+		}
+
+		s.dialed = time.Now()
+		addr := s.Servers[s.server]
+		c, err := DialServer(addr, s.Login, s.Timeout)
+		if err == nil {
+			return s.connected(addr, c)
+		}
+
+		log.Printf("Connecting to %s failed: %s, retrying in %s\n", addr, err, s.backoff)
+		s.server = (s.server + 1) % len(s.Servers)
+
+		select {
+		case <-s.closed:
+			return nil, io.EOF
+		case <-time.After(s.backoff):
+		}
+		s.growBackoff()
+	}
+}
+
+func (s *ReconnectingSource) growBackoff() {
+	s.backoff *= 2
+	if s.backoff > s.MaxBackoff {
+		s.backoff = s.MaxBackoff
+	}
+}
+
+func (s *ReconnectingSource) connected(addr string, c *ServerSource) (*ServerSource, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	select {
+	case <-s.closed:
+		c.Close()
+		return nil, io.EOF
+	default:
+	}
+
+	if s.last.IsZero() {
+		log.Printf("Connected to %s\n", addr)
+	} else {
+		log.Printf("Reconnected to %s, no data for %s\n", addr, time.Since(s.last))
+	}
+	s.current = c
+	return c, nil
+}
+
+func (s *ReconnectingSource) disconnect(c *ServerSource, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	select {
+	case <-s.closed:
+	default:
+		log.Printf("Lost connection to %s: %s\n", c.connection.RemoteAddr(), err)
+	}
+
+	c.Close()
+	s.current = nil
+
+	// fail over, the server may be in trouble
+	s.server = (s.server + 1) % len(s.Servers)
+
+	if !s.first.IsZero() && time.Since(s.first) >= stable_connection {
+		s.backoff = s.MinBackoff
+	} else {
+		s.growBackoff()
+	}
+	s.first = time.Time{}
+}
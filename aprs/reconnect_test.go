@@ -0,0 +1,50 @@
+package aprs
+
+import (
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// A server accepting and dropping every connection is redialled with a growing backoff.
+func TestReconnectBackoff(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	var accepted int32
+	go func() {
+		for {
+			c, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&accepted, 1)
+			fmt.Fprint(c, "# logresp OGNTEST unverified, server FAKE\r\n")
+			c.Close()
+		}
+	}()
+
+	s := NewReconnectingSource([]string{listener.Addr().String()}, "user OGNTEST pass -1\n")
+	s.MinBackoff = 10 * time.Millisecond
+	s.MaxBackoff = time.Second
+
+	go func() {
+		for {
+			if _, err := s.ReadLine(); err != nil {
+				return
+			}
+		}
+	}()
+	time.Sleep(500 * time.Millisecond)
+	s.Close()
+
+	// 10+20+40+80+160ms, a fixed backoff would have redialled about 50 times
+	if n := atomic.LoadInt32(&accepted); n < 2 || n > 8 {
+		t.Errorf("got %d connections", n)
+	}
+}
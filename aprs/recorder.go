@@ -0,0 +1,161 @@
+package aprs
+
+import (
+	"compress/gzip"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Recorder writes raw lines to daily gzip files (ogn.2015-08-28.log.gz) in Dir,
+// keeping the files of the last Retention days. A Retention of 0 keeps all files.
+type Recorder struct {
+	Dir       string
+	Retention int
+
+	mutex   sync.Mutex
+	day     string
+	file    *os.File
+	writer  *gzip.Writer
+	flushed time.Time
+}
+
+func NewRecorder(dir string, retention int) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Recorder{Dir: dir, Retention: retention}, nil
+}
+
+// NewRecorderFromEnv returns nil if RECORD_DIR is not set.
+func NewRecorderFromEnv() (*Recorder, error) {
+	dir := os.Getenv("RECORD_DIR")
+	if dir == "" {
+		return nil, nil
+	}
+	retention, _ := strconv.Atoi(os.Getenv("RECORD_RETENTION"))
+	return NewRecorder(dir, retention)
+}
+
+func (r *Recorder) Write(line string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now().UTC()
+	if day := now.Format("2006-01-02"); day != r.day {
+		if err := r.rotate(day); err != nil {
+			return err
+		}
+	}
+
+	if !strings.HasSuffix(line, "\n") {
+		line += "\n"
+	}
+	if _, err := r.writer.Write([]byte(line)); err != nil {
+		return err
+	}
+
+	// flushing every line would ruin the compression
+	if now.Sub(r.flushed) > 10*time.Second {
+		r.flushed = now
+		return r.writer.Flush()
+	}
+	return nil
+}
+
+func (r *Recorder) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.close()
+}
+
+func (r *Recorder) close() error {
+	if r.file == nil {
+		return nil
+	}
+
+	err := r.writer.Close()
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+	r.file = nil
+	r.writer = nil
+	// the next Write reopens the file
+	r.day = ""
+	return err
+}
+
+// Restarts on the same day append another gzip member to the file.
+func (r *Recorder) rotate(day string) error {
+	if err := r.close(); err != nil {
+		log.Println(err)
+	}
+
+	fn := filepath.Join(r.Dir, fmt.Sprintf("ogn.%s.log.gz", day))
+	f, err := os.OpenFile(fn, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	r.day = day
+	r.file = f
+	r.writer = gzip.NewWriter(f)
+	r.cleanup()
+	return nil
+}
+
+func (r *Recorder) cleanup() {
+	if r.Retention <= 0 {
+		return
+	}
+
+	files, err := filepath.Glob(filepath.Join(r.Dir, "ogn.*.log.gz"))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	// file names sort chronologically
+	sort.Strings(files)
+	for len(files) > r.Retention {
+		if err := os.Remove(files[0]); err != nil {
+			log.Println(err)
+		}
+		files = files[1:]
+	}
+}
+
+// RecordingSource tees every line read from Source into Recorder.
+type RecordingSource struct {
+	Source
+	Recorder *Recorder
+}
+
+func Record(source Source, recorder *Recorder) *RecordingSource {
+	return &RecordingSource{Source: source, Recorder: recorder}
+}
+
+func (s *RecordingSource) ReadLine() (string, error) {
+	line, err := s.Source.ReadLine()
+	if line != "" {
+		if rerr := s.Recorder.Write(line); rerr != nil {
+			log.Println(rerr)
+		}
+	}
+	return line, err
+}
+
+func (s *RecordingSource) Close() error {
+	err := s.Source.Close()
+	if rerr := s.Recorder.Close(); err == nil {
+		err = rerr
+	}
+	return err
+}
@@ -0,0 +1,57 @@
+package aprs
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecorderWriteAfterClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recorder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r, err := NewRecorder(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := []string{"# aprsc 2.1.4 28 Aug 2015 16:58:00 GMT GLIDERN1 1.2.3.4:14580", "FLRDDA5BA>APRS,qAS,LFMX:/165829h4415.41N/00600.03E'342/049/A=005524"}
+	if err := r.Write(lines[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// eg. a line still being read while Listen closes the source
+	if err := r.Write(lines[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	fn := filepath.Join(dir, "ogn."+time.Now().UTC().Format("2006-01-02")+".log.gz")
+	f, err := os.Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// both gzip members are read in turn
+	z, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(z)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := lines[0] + "\n" + lines[1] + "\n"; string(data) != want {
+		t.Errorf("got %q, want %q", data, want)
+	}
+}
@@ -0,0 +1,117 @@
+package aprs
+
+import (
+	"github.com/masone/ogn/clock"
+	"github.com/masone/ogn/packet"
+	"io"
+	"regexp"
+	"sync"
+	"time"
+)
+
+var (
+	// # aprsc 2.0.14-g28c5a6a 29 Aug 2015 21:16:01 GMT GLIDERN1 37.187.40.234:14580
+	server_time_matcher = regexp.MustCompile(`^# aprsc \S+ (\d{1,2} \w{3} \d{4} \d{2}:\d{2}:\d{2}) GMT`)
+	file_date_matcher   = regexp.MustCompile(`(\d{4}-\d{2}-\d{2})`)
+)
+
+// ReplaySource paces a recorded source by the beacon timestamps and moves the
+// simulated clock along. A Speed of 10 replays ten times faster than real time,
+// a Speed of 0 as fast as possible.
+//
+// Beacons only carry the time of day. Their date is inferred from the server
+// timestamps in the log, or from the date set with SetDate.
+type ReplaySource struct {
+	Source
+	Speed float64
+	Clock *clock.Simulated
+
+	last   time.Time
+	now    time.Time // reception time of the current line
+	fixed  bool
+	closed chan struct{}
+	once   sync.Once
+}
+
+func Replay(source Source, speed float64, c *clock.Simulated) *ReplaySource {
+	return &ReplaySource{Source: source, Speed: speed, Clock: c, now: time.Now(), closed: make(chan struct{})}
+}
+
+// SetDate sets the UTC date the replay starts on. If fixed, server timestamps
+// in the log are ignored.
+func (s *ReplaySource) SetDate(d time.Time, fixed bool) {
+	s.now = time.Date(d.Year(), d.Month(), d.Day(), 12, 0, 0, 0, time.UTC)
+	s.fixed = fixed
+}
+
+// DateFromFilename extracts the date of logfiles named like ogn.2015-08-28.log.
+func DateFromFilename(fn string) (time.Time, bool) {
+	m := file_date_matcher.FindStringSubmatch(fn)
+	if m == nil {
+		return time.Time{}, false
+	}
+	d, err := time.Parse("2006-01-02", m[1])
+	return d, err == nil
+}
+
+// Now returns the time the current line was received at.
+func (s *ReplaySource) Now() time.Time {
+	return s.now
+}
+
+func (s *ReplaySource) ReadLine() (string, error) {
+	line, err := s.Source.ReadLine()
+	if err == io.EOF {
+		defer s.Clock.Flush()
+	}
+	if line == "" {
+		return line, err
+	}
+
+	if m := server_time_matcher.FindStringSubmatch(line); m != nil {
+		if t, terr := time.Parse("2 Jan 2006 15:04:05", m[1]); terr == nil && !s.fixed {
+			s.now = t
+		}
+		return line, err
+	}
+
+	p, perr := packet.ParseAt(line, s.now)
+	if perr != nil || p.Timestamp.IsZero() {
+		return line, err
+	}
+
+	// following the beacons keeps the date right across midnight
+	t := p.Timestamp
+	s.now = t
+	if !s.last.IsZero() && t.After(s.last) {
+		if s.Speed > 0 && !s.wait(time.Duration(float64(t.Sub(s.last))/s.Speed)) {
+			return "", io.EOF
+		}
+	}
+	if s.last.IsZero() || t.After(s.last) {
+		s.last = t
+	}
+
+	s.Clock.Set(t)
+	return line, err
+}
+
+// wait returns false if the replay was closed in the meantime, eg. during an overnight gap.
+func (s *ReplaySource) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-s.closed:
+		return false
+	}
+}
+
+func (s *ReplaySource) Close() error {
+	s.once.Do(func() {
+		close(s.closed)
+	})
+	return s.Source.Close()
+}
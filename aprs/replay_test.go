@@ -0,0 +1,36 @@
+package aprs
+
+import (
+	"github.com/masone/ogn/clock"
+	"io"
+	"testing"
+	"time"
+)
+
+// A replay in real time doesn't sleep through a gap in the log once closed.
+func TestReplayCloseDuringGap(t *testing.T) {
+	replay := Replay(NewSliceSource([]string{
+		"FLRDDA5BA>APRS,qAS,LSPH:/080000h4415.41N/00600.03E'342/049/A=005524",
+		"FLRDDA5BA>APRS,qAS,LSPH:/200000h4415.41N/00600.03E'342/049/A=005524",
+	}), 1, clock.NewSimulated(time.Time{}))
+	replay.SetDate(time.Date(2015, 8, 28, 0, 0, 0, 0, time.UTC), true)
+
+	if _, err := replay.ReadLine(); err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(10*time.Millisecond, func() { replay.Close() })
+
+	done := make(chan error)
+	go func() {
+		_, err := replay.ReadLine()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != io.EOF {
+			t.Errorf("got %v, want EOF", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close didn't interrupt the replay")
+	}
+}
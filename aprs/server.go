@@ -0,0 +1,168 @@
+package aprs
+
+import (
+	"fmt"
+	"github.com/masone/ogn/config"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ServerSource is a logged in APRS-IS connection.
+// Lines starting with # are server comments (keepalives, login response). They are
+// handled here and passed on, so that recordings keep the server timestamps.
+// If neither data nor a keepalive arrives within the timeout, or the server
+// doesn't answer the login within the timeout, ReadLine fails so the connection
+// can be replaced.
+type ServerSource struct {
+	*ReaderSource
+	connection net.Conn
+	timeout    time.Duration
+	stop       chan struct{}
+	once       sync.Once
+	dialed     time.Time
+
+	Server   string // as reported by logresp
+	LoggedIn bool
+	Verified bool
+}
+
+func DialServer(addr string, login string, timeout time.Duration) (*ServerSource, error) {
+	connection, err := net.DialTimeout("tcp", addr, 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &ServerSource{
+		ReaderSource: NewReaderSource(connection),
+		connection:   connection,
+		timeout:      timeout,
+		stop:         make(chan struct{}),
+		dialed:       time.Now(),
+	}
+	if _, err := fmt.Fprint(connection, login); err != nil {
+		connection.Close()
+		return nil, err
+	}
+	s.keepalive()
+
+	return s, nil
+}
+
+// NewServerSource connects to the APRS-IS servers using the APRS_* and AF_* env variables.
+func NewServerSource() (*ReconnectingSource, error) {
+	s := NewReconnectingSource(servers(), login())
+	s.Timeout = timeout()
+	return s, nil
+}
+
+func servers() []string {
+	list := os.Getenv("APRS_SERVERS")
+	if list == "" {
+		return []string{"aprs.glidernet.org:14580"}
+	}
+
+	var servers []string
+	for _, server := range strings.Split(list, ",") {
+		if server = strings.TrimSpace(server); server != "" {
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+func login() string {
+	// one range filter per airfield, APRS-IS combines them
+	var filters []string
+	for _, af := range config.Airfields() {
+		filters = append(filters, fmt.Sprintf("r/%g/%g/%s", af.Lat, af.Lng, os.Getenv("APRS_RADIUS")))
+	}
+
+	return fmt.Sprintf("user %s pass -1 vers ogn 0.0.1 filter %s\n",
+		os.Getenv("APRS_USER"),
+		strings.Join(filters, " "),
+	)
+}
+
+// in seconds
+func timeout() time.Duration {
+	t, err := strconv.Atoi(os.Getenv("APRS_TIMEOUT"))
+	if err != nil || t <= 0 {
+		return 2 * time.Minute
+	}
+	return time.Duration(t) * time.Second
+}
+
+func (s *ServerSource) ReadLine() (string, error) {
+	if s.timeout > 0 {
+		deadline := time.Now().Add(s.timeout)
+		if !s.LoggedIn {
+			deadline = s.dialed.Add(s.timeout)
+		}
+		s.connection.SetReadDeadline(deadline)
+	}
+
+	line, err := s.ReaderSource.ReadLine()
+	if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		if !s.LoggedIn {
+			return "", fmt.Errorf("no login response within %s", s.timeout)
+		}
+		return "", fmt.Errorf("no data or keepalive for %s", s.timeout)
+	}
+	if err == nil && strings.HasPrefix(line, "#") {
+		s.handleComment(strings.TrimSpace(line))
+	}
+	// without a login response the server may ignore the filter
+	if err == nil && !s.LoggedIn && s.timeout > 0 && time.Since(s.dialed) > s.timeout {
+		return "", fmt.Errorf("no login response within %s", s.timeout)
+	}
+	return line, err
+}
+
+// # aprsc 2.1.4-g408ed49 16 Oct 2026 12:00:00 GMT GLIDERN1 1.2.3.4:14580
+// # logresp OGN123 unverified, server GLIDERN1
+func (s *ServerSource) handleComment(c string) {
+	items := strings.Fields(c)
+	if len(items) < 4 || items[1] != "logresp" {
+		return
+	}
+
+	s.LoggedIn = true
+	s.Verified = strings.TrimSuffix(items[3], ",") == "verified"
+	s.Server = items[len(items)-1]
+	if !s.Verified {
+		// expected with pass -1, the connection is receive only
+		log.Printf("Warning: login as %s unverified on %s\n", items[2], s.Server)
+		return
+	}
+	log.Printf("Logged in as %s on %s\n", items[2], s.Server)
+}
+
+func (s *ServerSource) keepalive() {
+	ticker := time.NewTicker(30 * time.Second)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case t := <-ticker.C:
+				fmt.Fprintf(s.connection, "# ogn keepalive %s\n", t)
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (s *ServerSource) Close() error {
+	var err error
+	s.once.Do(func() {
+		close(s.stop)
+		err = s.connection.Close()
+	})
+	return err
+}
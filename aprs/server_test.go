@@ -0,0 +1,66 @@
+package aprs
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleComment(t *testing.T) {
+	tests := []struct {
+		comment  string
+		loggedIn bool
+		verified bool
+		server   string
+	}{
+		{"# logresp OGN123 unverified, server GLIDERN1", true, false, "GLIDERN1"},
+		{"# logresp OGN123 verified, server GLIDERN2", true, true, "GLIDERN2"},
+		{"# aprsc 2.1.4-g408ed49 16 Oct 2026 12:00:00 GMT GLIDERN1 1.2.3.4:14580", false, false, ""},
+		{"# ogn keepalive", false, false, ""},
+	}
+
+	for _, test := range tests {
+		s := &ServerSource{}
+		s.handleComment(test.comment)
+		if s.LoggedIn != test.loggedIn || s.Verified != test.verified || s.Server != test.server {
+			t.Errorf("%s: got %+v", test.comment, s)
+		}
+	}
+}
+
+// A server sending its banner and data but never answering the login is given up.
+func TestServerSourceNoLogresp(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	go func() {
+		c, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		fmt.Fprint(c, "# aprsc 2.1.4-g408ed49 16 Oct 2026 12:00:00 GMT GLIDERN1 1.2.3.4:14580\r\n")
+		time.Sleep(time.Second)
+	}()
+
+	s, err := DialServer(listener.Addr().String(), "user OGNTEST pass -1\n", 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if line, err := s.ReadLine(); err != nil || !strings.HasPrefix(line, "# aprsc") {
+		t.Fatalf("got %q, %v", line, err)
+	}
+	if _, err := s.ReadLine(); err == nil || !strings.Contains(err.Error(), "login") {
+		t.Errorf("got %v, want the missing login response", err)
+	}
+	if s.LoggedIn {
+		t.Error("logged in without logresp")
+	}
+}
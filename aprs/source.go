@@ -0,0 +1,71 @@
+package aprs
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sync"
+)
+
+// Source delivers raw APRS lines. ReadLine returns io.EOF when the source is exhausted.
+type Source interface {
+	ReadLine() (string, error)
+	Close() error
+}
+
+type ReaderSource struct {
+	reader *bufio.Reader
+	closer io.Closer
+}
+
+func NewReaderSource(r io.Reader) *ReaderSource {
+	s := &ReaderSource{reader: bufio.NewReader(r)}
+	if c, ok := r.(io.Closer); ok {
+		s.closer = c
+	}
+	return s
+}
+
+func NewStdinSource() *ReaderSource {
+	return &ReaderSource{reader: bufio.NewReader(os.Stdin)}
+}
+
+func (s *ReaderSource) ReadLine() (string, error) {
+	return s.reader.ReadString('\n')
+}
+
+func (s *ReaderSource) Close() error {
+	if s.closer != nil {
+		return s.closer.Close()
+	}
+	return nil
+}
+
+type SliceSource struct {
+	lines []string
+	mutex sync.Mutex
+}
+
+func NewSliceSource(lines []string) *SliceSource {
+	return &SliceSource{lines: lines}
+}
+
+func (s *SliceSource) ReadLine() (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.lines) == 0 {
+		return "", io.EOF
+	}
+	line := s.lines[0]
+	s.lines = s.lines[1:]
+	return line, nil
+}
+
+func (s *SliceSource) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.lines = nil
+	return nil
+}
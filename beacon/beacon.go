@@ -0,0 +1,151 @@
+package beacon
+
+import (
+	"fmt"
+	"github.com/masone/ogn/flarm"
+	"github.com/masone/ogn/packet"
+	"strings"
+)
+
+type Family string
+
+const (
+	UNKNOWN     Family = ""
+	FLARM       Family = "flarm"
+	OGN_TRACKER Family = "ogn-tracker"
+	ADSB        Family = "adsb"
+	FANET       Family = "fanet"
+	PILOTAWARE  Family = "pilotaware"
+	SPOT        Family = "spot"
+	RECEIVER    Family = "receiver"
+	OTHER       Family = "other" // OGN tocalls not in the table, read like ADS-B
+)
+
+// OGN beacons are told apart by their destination callsign (tocall).
+// http://wiki.glidernet.org/wiki:ogn-flavoured-aprs
+var tocalls = map[string]Family{
+	"OGFLR":  FLARM,
+	"OGNTRK": OGN_TRACKER,
+	"OGADSB": ADSB,
+	"OGNFNT": FANET,
+	"OGPAW":  PILOTAWARE,
+	"OGSPOT": SPOT,
+	"OGNSDR": RECEIVER,
+}
+
+// Position is an aircraft position normalised across the beacon families.
+type Position struct {
+	*packet.Packet
+	flarm.Comment
+	Family    Family
+	Receiver  string // ground station which received the beacon
+	RelayedBy string // device which relayed the beacon, empty if received directly
+}
+
+// Dispatcher hands each beacon to the handler of its family.
+type Dispatcher struct {
+	Aircraft func(pos *Position) error
+	Receiver func(p *packet.Packet) error
+}
+
+func (d *Dispatcher) Dispatch(p *packet.Packet) error {
+	family := Classify(p)
+	switch family {
+	case RECEIVER:
+		if d.Receiver == nil {
+			return nil
+		}
+		return d.Receiver(p)
+	case UNKNOWN:
+		return fmt.Errorf("beacon: unknown beacon type %s", p.DstCallsign)
+	}
+
+	if p.Type != packet.LOCATION {
+		return nil
+	}
+
+	pos, err := Parse(p, family)
+	if err != nil {
+		return err
+	}
+	if d.Aircraft == nil {
+		return nil
+	}
+	return d.Aircraft(pos)
+}
+
+// New OGN tocalls (OGNAVI, OGFLYM, OGNSXR, ...) are not an error: receivers are
+// told by their path, other devices fall back to the generic comment dialect.
+func Classify(p *packet.Packet) Family {
+	if f, ok := tocalls[p.DstCallsign]; ok {
+		return f
+	}
+	ogn := strings.HasPrefix(p.DstCallsign, "OG")
+	if !ogn && p.DstCallsign != "APRS" {
+		return UNKNOWN
+	}
+
+	// receivers log in to APRS-IS themselves: APRS,TCPIP*,qAC
+	for _, item := range p.Path {
+		if item == "qAC" || item == "TCPIP*" {
+			return RECEIVER
+		}
+	}
+	if ogn {
+		return OTHER
+	}
+
+	// legacy format: APRS,qAS aircraft beacons
+	if strings.HasPrefix(p.SrcCallsign, "OGN") {
+		return OGN_TRACKER
+	}
+	return FLARM
+}
+
+// Parse reads the comment dialect of the family.
+func Parse(p *packet.Packet, family Family) (*Position, error) {
+	var c flarm.Comment
+	var err error
+
+	switch family {
+	case FLARM, OGN_TRACKER:
+		c, err = flarm.ParseComment(p.Comment)
+	default:
+		c, err = parseGeneric(p)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Position{Packet: p, Comment: c, Family: family, Receiver: receivedBy(p), RelayedBy: relayedBy(p)}, nil
+}
+
+// qAS,LSPH: received by LSPH
+func receivedBy(p *packet.Packet) string {
+	for i, item := range p.Path {
+		if item == "qAS" && i+1 < len(p.Path) {
+			return p.Path[i+1]
+		}
+	}
+	return ""
+}
+
+// Relayed beacons carry the relaying device in the path: FLRDDE48A>OGFLR,OGN3FC859*,qAS,LSPH
+func relayedBy(p *packet.Packet) string {
+	for _, item := range p.Path {
+		if strings.HasSuffix(item, "*") && item != "TCPIP*" {
+			return strings.TrimSuffix(item, "*")
+		}
+	}
+	return ""
+}
+
+// ADS-B, FANET, PilotAware, SPOT and other comments vary, only the known tokens are used.
+// Without an id token (eg. SPOT), the source callsign identifies the device.
+func parseGeneric(p *packet.Packet) (flarm.Comment, error) {
+	c := flarm.ParseCommentLenient(p.Comment)
+	if c.Id == "" {
+		c.Device = flarm.Device{Id: p.SrcCallsign, AddressType: flarm.UNKNOWN_ADDRESS}
+	}
+	return c, nil
+}
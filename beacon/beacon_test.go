@@ -0,0 +1,52 @@
+package beacon
+
+import (
+	"github.com/masone/ogn/packet"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		line   string
+		family Family
+	}{
+		{`FLRDDA5BA>OGFLR,qAS,LSPH:/165829h4415.41N/00600.03E'342/049/A=005524 id0ADDA5BA -454fpm -1.1rot`, FLARM},
+		{`FLRDDA5BA>APRS,qAS,LSPH:/165829h4415.41N/00600.03E'342/049/A=005524 id0ADDA5BA -454fpm -1.1rot`, FLARM},
+		{`OGN123456>APRS,qAS,LSPH:/165829h4415.41N/00600.03E'342/049/A=005524 id07123456 -454fpm`, OGN_TRACKER},
+		{`LSPH>OGNSDR,TCPIP*,qAC,GLIDERN2:/132201h4657.02NI00722.57E&/A=001690`, RECEIVER},
+		{`LSPH>APRS,TCPIP*,qAC,GLIDERN2:/132201h4657.02NI00722.57E&/A=001690`, RECEIVER},
+		{`LSPH>OGNSXR,TCPIP*,qAC,GLIDERN2:/132201h4657.02NI00722.57E&/A=001690`, RECEIVER},
+		{`NAV042121>OGNAVI,qAS,NAVITER:/165829h4415.41N/00600.03E'342/049/A=005524 !W33! id1C042121 +000fpm +0.0rot`, OTHER},
+		{`FLRDDA5BA>APN123,qAS,LSPH:/165829h4415.41N/00600.03E'342/049/A=005524`, UNKNOWN},
+	}
+
+	for _, test := range tests {
+		p, err := packet.Parse(test.line)
+		if err != nil {
+			t.Fatalf("%s: %v", test.line, err)
+		}
+		if f := Classify(p); f != test.family {
+			t.Errorf("%s: got %q, want %q", test.line, f, test.family)
+		}
+	}
+}
+
+// Beacons of unhandled OGN tocalls are not rejected.
+func TestDispatchOther(t *testing.T) {
+	p, err := packet.Parse(`NAV042121>OGNAVI,qAS,NAVITER:/165829h4415.41N/00600.03E'342/049/A=005524 !W33! id1C042121 +000fpm +0.0rot`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got *Position
+	d := Dispatcher{Aircraft: func(pos *Position) error {
+		got = pos
+		return nil
+	}}
+	if err := d.Dispatch(p); err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.Family != OTHER || got.Id != "042121" {
+		t.Errorf("got %+v", got)
+	}
+}
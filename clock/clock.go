@@ -0,0 +1,112 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock abstracts the passing of time, so replays can run faster than real time.
+type Clock interface {
+	Now() time.Time
+	AfterFunc(d time.Duration, f func())
+}
+
+type Real struct{}
+
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+func (Real) AfterFunc(d time.Duration, f func()) {
+	time.AfterFunc(d, f)
+}
+
+// Simulated only moves when Set is called. Due functions are run
+// synchronously by Set, in the order of their due time.
+// A clock created with the zero time starts with the first Set,
+// functions scheduled before are due relative to that.
+type Simulated struct {
+	mutex  sync.Mutex
+	now    time.Time
+	timers []timer
+}
+
+type timer struct {
+	due   time.Time
+	delay time.Duration
+	f     func()
+}
+
+func NewSimulated(t time.Time) *Simulated {
+	return &Simulated{now: t}
+}
+
+func (c *Simulated) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+func (c *Simulated) AfterFunc(d time.Duration, f func()) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.timers = append(c.timers, timer{due: c.now.Add(d), delay: d, f: f})
+	c.sort()
+}
+
+func (c *Simulated) sort() {
+	sort.SliceStable(c.timers, func(i, j int) bool {
+		return c.timers[i].due.Before(c.timers[j].due)
+	})
+}
+
+// Set advances the clock to t. The clock never moves backwards.
+func (c *Simulated) Set(t time.Time) {
+	c.mutex.Lock()
+	if c.now.IsZero() && !t.IsZero() {
+		c.now = t
+		for i := range c.timers {
+			c.timers[i].due = t.Add(c.timers[i].delay)
+		}
+		c.sort()
+	}
+	c.mutex.Unlock()
+
+	for {
+		c.mutex.Lock()
+		if len(c.timers) == 0 || c.timers[0].due.After(t) {
+			if t.After(c.now) {
+				c.now = t
+			}
+			c.mutex.Unlock()
+			return
+		}
+
+		next := c.timers[0]
+		c.timers = c.timers[1:]
+		if next.due.After(c.now) {
+			c.now = next.due
+		}
+		c.mutex.Unlock()
+
+		next.f()
+	}
+}
+
+// Flush runs all pending functions, eg. at the end of a replay.
+func (c *Simulated) Flush() {
+	c.mutex.Lock()
+	n := len(c.timers)
+	var last time.Time
+	if n > 0 {
+		last = c.timers[n-1].due
+	}
+	c.mutex.Unlock()
+
+	if n > 0 {
+		c.Set(last)
+	}
+}
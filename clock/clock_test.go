@@ -0,0 +1,113 @@
+package clock
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var noon = time.Date(2015, 8, 28, 12, 0, 0, 0, time.UTC)
+
+func TestSimulated(t *testing.T) {
+	tests := []struct {
+		name   string
+		start  time.Time
+		delays []time.Duration // scheduled before the first Set, named by their index
+		set    []time.Time
+		want   []int
+		now    time.Time
+	}{
+		{
+			name:   "due in order",
+			start:  noon,
+			delays: []time.Duration{3 * time.Minute, time.Minute, 2 * time.Minute},
+			set:    []time.Time{noon.Add(5 * time.Minute)},
+			want:   []int{1, 2, 0},
+			now:    noon.Add(5 * time.Minute),
+		},
+		{
+			name:   "only due ones",
+			start:  noon,
+			delays: []time.Duration{time.Minute, 10 * time.Minute},
+			set:    []time.Time{noon.Add(5 * time.Minute)},
+			want:   []int{0},
+			now:    noon.Add(5 * time.Minute),
+		},
+		{
+			name:   "never backwards",
+			start:  noon,
+			delays: []time.Duration{time.Minute},
+			set:    []time.Time{noon.Add(5 * time.Minute), noon},
+			want:   []int{0},
+			now:    noon.Add(5 * time.Minute),
+		},
+		{
+			name:   "rebased from the zero time",
+			delays: []time.Duration{time.Minute, 10 * time.Minute},
+			set:    []time.Time{noon, noon.Add(2 * time.Minute)},
+			want:   []int{0},
+			now:    noon.Add(2 * time.Minute),
+		},
+	}
+
+	for _, test := range tests {
+		c := NewSimulated(test.start)
+		var got []int
+		for i, d := range test.delays {
+			i := i
+			c.AfterFunc(d, func() { got = append(got, i) })
+		}
+		for _, s := range test.set {
+			c.Set(s)
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: ran %v, want %v", test.name, got, test.want)
+		}
+		if !c.Now().Equal(test.now) {
+			t.Errorf("%s: now %s, want %s", test.name, c.Now(), test.now)
+		}
+	}
+}
+
+// Functions see the clock at their due time and may schedule more.
+func TestSimulatedNowInFunctions(t *testing.T) {
+	c := NewSimulated(noon)
+	var seen []time.Time
+	var tick func()
+	tick = func() {
+		seen = append(seen, c.Now())
+		if len(seen) < 3 {
+			c.AfterFunc(time.Minute, tick)
+		}
+	}
+	c.AfterFunc(time.Minute, tick)
+	c.Set(noon.Add(10 * time.Minute))
+
+	want := []time.Time{noon.Add(time.Minute), noon.Add(2 * time.Minute), noon.Add(3 * time.Minute)}
+	if !reflect.DeepEqual(seen, want) {
+		t.Errorf("got %v, want %v", seen, want)
+	}
+}
+
+// At the end of a replay, the launch type detections still pending are run.
+func TestSimulatedFlush(t *testing.T) {
+	c := NewSimulated(noon)
+	var got []int
+	c.AfterFunc(20*time.Second, func() { got = append(got, 0) })
+	c.AfterFunc(time.Hour, func() { got = append(got, 1) })
+	c.Flush()
+
+	if !reflect.DeepEqual(got, []int{0, 1}) {
+		t.Errorf("ran %v", got)
+	}
+	if !c.Now().Equal(noon.Add(time.Hour)) {
+		t.Errorf("now %s", c.Now())
+	}
+
+	// nothing pending
+	c.Flush()
+	if len(got) != 2 {
+		t.Errorf("ran %v", got)
+	}
+}
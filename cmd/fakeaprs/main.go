@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"github.com/masone/ogn/aprs"
+	"github.com/masone/ogn/clock"
+	"github.com/masone/ogn/fakeaprs"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// fakeaprs -listen :14580 -speed 10 ogn.2015-08-28.log
+func main() {
+	listen := flag.String("listen", "127.0.0.1:14580", "address to listen on")
+	speed := flag.Float64("speed", 1, "replay speed factor, 0 sends as fast as possible")
+	disconnect := flag.Int("disconnect", 0, "drop clients after that many lines")
+	garbage := flag.Int("garbage", 0, "send an unparseable line every that many lines")
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		log.Fatal("usage: fakeaprs [options] file.log [file.log.gz ...]")
+	}
+
+	server := fakeaprs.NewServer(func() (aprs.Source, error) {
+		files, err := aprs.NewFilesSource(flag.Args()...)
+		if err != nil {
+			return nil, err
+		}
+		replay := aprs.Replay(files, *speed, clock.NewSimulated(time.Time{}))
+		if d, ok := aprs.DateFromFilename(files.Files[0]); ok {
+			replay.SetDate(d, false)
+		}
+		return replay, nil
+	})
+	server.DisconnectAfter = *disconnect
+	server.GarbageEvery = *garbage
+
+	if err := server.Start(*listen); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Listening on %s\n", server.Addr())
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	<-interrupt
+	server.Close()
+}
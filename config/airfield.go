@@ -0,0 +1,271 @@
+package config
+
+import (
+	"fmt"
+	"github.com/masone/ogn/geofence"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Airfield holds the position of the airfield and the parameters of the start and landing detection.
+type Airfield struct {
+	Name      string
+	Lat       float64
+	Lng       float64
+	Elevation float64 // in meters
+	Timezone  string
+	Geofence  string // GeoJSON or KML file with the ground of the airfield
+
+	// loaded from the geofence file, checked instead of the distance threshold
+	Fence *geofence.Fence
+
+	ElevationThreshold   float64       // in meters around the elevation counting as on ground
+	DistanceThreshold    float64       // in kilometers from the airfield counting as on the field
+	WinchLaunchThreshold float64       // in meters of height gained by a winch launch
+	WinchClimbThreshold  float64       // in m/s, aerotows climb at 2-4 m/s
+	TowThreshold         float64       // in meters between a glider and its tow plane
+	LaunchDelay          time.Duration // after the start until the launch type is detected
+	PositionWindow       time.Duration // in which the last position tells a start or landing
+	QueryWindow          time.Duration // around a start to look for climb, height and parallel starts
+}
+
+// DefaultAirfield has the parameters which work well for our airfield, tow plane type and winch cord length.
+var DefaultAirfield = Airfield{
+	ElevationThreshold:   20,
+	DistanceThreshold:    0.5,
+	WinchLaunchThreshold: 200,
+	WinchClimbThreshold:  15,
+	TowThreshold:         20,
+	LaunchDelay:          20 * time.Second,
+	PositionWindow:       5 * time.Minute,
+	QueryWindow:          30 * time.Second,
+}
+
+var airfields = []Airfield{DefaultAirfield}
+
+// GetAirfield returns the first airfield loaded by Load, the home of the tracker.
+func GetAirfield() Airfield {
+	return airfields[0]
+}
+
+// Airfields returns all airfields loaded by Load.
+func Airfields() []Airfield {
+	return append([]Airfield(nil), airfields...)
+}
+
+// LoadAirfields reads the profiles from AF_PROFILE, a comma separated list of files.
+// The AF_* env variables override the first profile:
+//
+//	name: Buttwil
+//	lat: 47.1786
+//	lng: 8.3095
+//	elevation: 715
+//	timezone: Europe/Zurich
+//	geofence: buttwil.geojson
+//	elevation_threshold: 20
+//	distance_threshold: 0.5
+//	winch_launch_threshold: 200
+//	winch_climb_threshold: 15
+//	tow_threshold: 20
+//	launch_delay: 20s
+//	position_window: 5m
+//	query_window: 30s
+//
+// The time zone and windows of the first airfield apply to all of them,
+// the other profiles inherit them and may not set different ones.
+func LoadAirfields() error {
+	files := []string{""}
+	if list := os.Getenv("AF_PROFILE"); list != "" {
+		files = strings.Split(list, ",")
+	}
+
+	var list []Airfield
+	names := make(map[string]bool)
+	for i, fn := range files {
+		defaults := DefaultAirfield
+		if i > 0 {
+			defaults.Timezone = list[0].Timezone
+			defaults.PositionWindow = list[0].PositionWindow
+			defaults.QueryWindow = list[0].QueryWindow
+		}
+
+		af, err := loadAirfield(strings.TrimSpace(fn), defaults, i == 0)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			if err := af.sameShared(list[0]); err != nil {
+				return fmt.Errorf("config: %s: %s", fn, err)
+			}
+		}
+
+		// flights are attributed to the airfields by name
+		if len(files) > 1 {
+			if af.Name == "" {
+				return fmt.Errorf("config: %s: a name is required for multiple airfields", fn)
+			}
+			if names[af.Name] {
+				return fmt.Errorf("config: %s: duplicate airfield %s", fn, af.Name)
+			}
+			names[af.Name] = true
+		}
+		list = append(list, af)
+	}
+
+	airfields = list
+	return nil
+}
+
+func loadAirfield(fn string, defaults Airfield, first bool) (Airfield, error) {
+	af := defaults
+	if fn != "" {
+		root, err := ReadYaml(fn)
+		if err != nil {
+			return af, fmt.Errorf("config: %s", err)
+		}
+		for name, node := range root {
+			v, ok := Value(node)
+			if !ok {
+				return af, fmt.Errorf("config: %s: %s is not a value", fn, name)
+			}
+			if err := af.set(name, v); err != nil {
+				return af, fmt.Errorf("config: %s: %s", fn, err)
+			}
+		}
+
+		// next to the profile, wherever the tracker is started from
+		if af.Geofence != "" && !filepath.IsAbs(af.Geofence) {
+			af.Geofence = filepath.Join(filepath.Dir(fn), af.Geofence)
+		}
+	}
+
+	if first {
+		for name, key := range map[string]string{"lat": "AF_LAT", "lng": "AF_LNG", "elevation": "AF_ELEVATION", "timezone": "AF_TIMEZONE", "geofence": "AF_GEOFENCE"} {
+			if v := os.Getenv(key); v != "" {
+				if err := af.set(name, v); err != nil {
+					return af, fmt.Errorf("config: %s: %s", key, err)
+				}
+			}
+		}
+	}
+
+	if af.Geofence != "" {
+		fence, err := geofence.Load(af.Geofence)
+		if err != nil {
+			return af, err
+		}
+		af.Fence = fence
+
+		// the center of the geofence for the APRS filter and receiver distances
+		if af.Lat == 0 && af.Lng == 0 {
+			center := fence.Center()
+			af.Lat, af.Lng = center.Lat(), center.Lng()
+		}
+	}
+
+	return af, af.validate()
+}
+
+// sameShared checks the settings which only the first airfield can set.
+func (af *Airfield) sameShared(first Airfield) error {
+	if af.Timezone != first.Timezone {
+		return fmt.Errorf("timezone %s differs from %s of the first airfield, which applies to all", af.Timezone, first.Timezone)
+	}
+	if af.PositionWindow != first.PositionWindow {
+		return fmt.Errorf("position_window %s differs from %s of the first airfield, which applies to all", af.PositionWindow, first.PositionWindow)
+	}
+	if af.QueryWindow != first.QueryWindow {
+		return fmt.Errorf("query_window %s differs from %s of the first airfield, which applies to all", af.QueryWindow, first.QueryWindow)
+	}
+	return nil
+}
+
+func (af *Airfield) set(name string, v string) error {
+	floats := map[string]*float64{
+		"lat":                    &af.Lat,
+		"lng":                    &af.Lng,
+		"elevation":              &af.Elevation,
+		"elevation_threshold":    &af.ElevationThreshold,
+		"distance_threshold":     &af.DistanceThreshold,
+		"winch_launch_threshold": &af.WinchLaunchThreshold,
+		"winch_climb_threshold":  &af.WinchClimbThreshold,
+		"tow_threshold":          &af.TowThreshold,
+	}
+	durations := map[string]*time.Duration{
+		"launch_delay":    &af.LaunchDelay,
+		"position_window": &af.PositionWindow,
+		"query_window":    &af.QueryWindow,
+	}
+
+	if f, ok := floats[name]; ok {
+		var err error
+		if *f, err = strconv.ParseFloat(v, 64); err != nil {
+			return fmt.Errorf("%s: invalid number %q", name, v)
+		}
+		return nil
+	}
+	if d, ok := durations[name]; ok {
+		var err error
+		if *d, err = time.ParseDuration(v); err != nil {
+			return fmt.Errorf("%s: invalid duration %q, expected eg. 30s or 5m", name, v)
+		}
+		return nil
+	}
+
+	switch name {
+	case "name":
+		af.Name = v
+	case "timezone":
+		af.Timezone = v
+	case "geofence":
+		af.Geofence = v
+	default:
+		return fmt.Errorf("unknown parameter %q", name)
+	}
+	return nil
+}
+
+func (af *Airfield) validate() error {
+	// 0,0 is in the Gulf of Guinea, the position was forgotten
+	if af.Lat == 0 && af.Lng == 0 {
+		return fmt.Errorf("config: airfield %s needs lat and lng (AF_LAT, AF_LNG) or a geofence", af.Name)
+	}
+	if af.Lat < -90 || af.Lat > 90 || af.Lng < -180 || af.Lng > 180 {
+		return fmt.Errorf("config: invalid airfield position %g,%g", af.Lat, af.Lng)
+	}
+	if af.Timezone != "" {
+		if _, err := time.LoadLocation(af.Timezone); err != nil {
+			return fmt.Errorf("config: unknown timezone %s", af.Timezone)
+		}
+	}
+
+	for name, f := range map[string]float64{
+		"elevation_threshold":    af.ElevationThreshold,
+		"distance_threshold":     af.DistanceThreshold,
+		"winch_launch_threshold": af.WinchLaunchThreshold,
+		"winch_climb_threshold":  af.WinchClimbThreshold,
+		"tow_threshold":          af.TowThreshold,
+	} {
+		if f <= 0 {
+			return fmt.Errorf("config: %s must be positive", name)
+		}
+	}
+	for name, d := range map[string]time.Duration{
+		"launch_delay":    af.LaunchDelay,
+		"position_window": af.PositionWindow,
+		"query_window":    af.QueryWindow,
+	} {
+		if d <= 0 {
+			return fmt.Errorf("config: %s must be positive", name)
+		}
+	}
+
+	// the launch is detected from the positions around the start
+	if af.LaunchDelay > af.QueryWindow {
+		return fmt.Errorf("config: launch_delay must not exceed query_window")
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, fn string, data string) {
+	if err := ioutil.WriteFile(fn, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// The geofence is found next to the profile, not in the working directory.
+func TestLoadAirfieldGeofence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeFile(t, filepath.Join(dir, "field.geojson"), `{"type": "Polygon", "coordinates": [[[8.30, 47.17], [8.32, 47.17], [8.32, 47.18], [8.30, 47.17]]]}`)
+	writeFile(t, filepath.Join(dir, "buttwil.yml"), "name: Buttwil\ngeofence: field.geojson\n")
+
+	af, err := loadAirfield(filepath.Join(dir, "buttwil.yml"), DefaultAirfield, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if af.Fence == nil || !af.Fence.Contains(47.172, 8.31) {
+		t.Errorf("got fence %+v", af.Fence)
+	}
+	if af.Lat != 47.175 || af.Lng != 8.31 {
+		t.Errorf("got center %g,%g", af.Lat, af.Lng)
+	}
+}
+
+func TestLoadAirfieldsShared(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("AF_PROFILE", os.Getenv("AF_PROFILE"))
+	defer func() { airfields = []Airfield{DefaultAirfield} }()
+
+	first := filepath.Join(dir, "buttwil.yml")
+	writeFile(t, first, "name: Buttwil\nlat: 47.1786\nlng: 8.3095\ntimezone: Europe/Zurich\nquery_window: 40s\n")
+
+	tests := []struct {
+		profile string
+		ok      bool
+	}{
+		{"name: Hausen\nlat: 47.2411\nlng: 8.5161\n", true},
+		{"name: Hausen\nlat: 47.2411\nlng: 8.5161\ntimezone: Europe/Zurich\nquery_window: 40s\n", true},
+		{"name: Hausen\nlat: 47.2411\nlng: 8.5161\ntimezone: Europe/Berlin\n", false},
+		{"name: Hausen\nlat: 47.2411\nlng: 8.5161\nposition_window: 10m\n", false},
+		{"name: Hausen\nlat: 47.2411\nlng: 8.5161\nquery_window: 30s\n", false},
+	}
+	for _, test := range tests {
+		second := filepath.Join(dir, "hausen.yml")
+		writeFile(t, second, test.profile)
+		os.Setenv("AF_PROFILE", first+","+second)
+
+		err := LoadAirfields()
+		if test.ok != (err == nil) {
+			t.Errorf("%q: got %v", test.profile, err)
+			continue
+		}
+		// inherited from the first profile
+		if err == nil && (airfields[1].Timezone != "Europe/Zurich" || airfields[1].QueryWindow != 40*time.Second) {
+			t.Errorf("%q: got %+v", test.profile, airfields[1])
+		}
+	}
+}
+
+func TestLoadAirfieldPosition(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fn := filepath.Join(dir, "hausen.yml")
+	writeFile(t, fn, "name: Hausen\nelevation: 450\n")
+	if _, err := loadAirfield(fn, DefaultAirfield, false); err == nil {
+		t.Error("want an error without a position")
+	}
+
+	writeFile(t, fn, "name: Hausen\nlat: 47.2411\nlng: 8.5161\n")
+	if _, err := loadAirfield(fn, DefaultAirfield, false); err != nil {
+		t.Error(err)
+	}
+}
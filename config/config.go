@@ -3,11 +3,41 @@ package config
 import (
 	"github.com/joho/godotenv"
 	"log"
+	"strings"
+	"time"
 )
 
-func Load() {
+// Load reads the .env file and the airfield profiles.
+func Load() error {
 	err := godotenv.Load()
 	if err != nil {
 		log.Println("Error loading .env file, falling back to system ENV variables")
 	}
+	return LoadAirfields()
+}
+
+// Location is the time zone of the first airfield (AF_TIMEZONE), defaulting to the system's.
+func Location() *time.Location {
+	tz := airfields[0].Timezone
+	if tz == "" {
+		return time.Local
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		log.Printf("Unknown timezone %s, falling back to %s\n", tz, time.Local)
+		return time.Local
+	}
+	return loc
+}
+
+// List splits a comma separated setting, eg. "LSPH, LSZF", skipping empty entries.
+func List(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
 }
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestList(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []string
+	}{
+		{"", nil},
+		{"LSPH", []string{"LSPH"}},
+		{"LSPH, LSZF", []string{"LSPH", "LSZF"}},
+		{" LSPH ,,LSZF, ", []string{"LSPH", "LSZF"}},
+	}
+
+	for _, test := range tests {
+		if got := List(test.s); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %q, want %q", test.s, got, test.want)
+		}
+	}
+}
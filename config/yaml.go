@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"github.com/kylelemons/go-gypsy/yaml"
+	"strings"
+)
+
+// ReadYaml reads a YAML file which has to be a map at the top level. An empty file is an empty map.
+func ReadYaml(fn string) (yaml.Map, error) {
+	f, err := yaml.ReadFile(fn)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", fn, err)
+	}
+	if f.Root == nil {
+		return yaml.Map{}, nil
+	}
+
+	root, ok := f.Root.(yaml.Map)
+	if !ok {
+		return nil, fmt.Errorf("%s: expected a map", fn)
+	}
+	return root, nil
+}
+
+// Value returns the scalar of a node without quotes. go-gypsy only skips comment lines,
+// not comments after values. A # inside quotes is part of the value.
+func Value(node yaml.Node) (string, bool) {
+	scalar, ok := node.(yaml.Scalar)
+	if !ok {
+		return "", false
+	}
+
+	v := strings.TrimSpace(stripComment(string(scalar)))
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+		v = v[1 : len(v)-1]
+	}
+	return v, true
+}
+
+// stripComment cuts the value at the first " #" after the closing quote of a quoted value.
+func stripComment(v string) string {
+	start := 0
+	if t := strings.TrimLeft(v, " \t"); len(t) > 0 && (t[0] == '"' || t[0] == '\'') {
+		if end := strings.IndexByte(t[1:], t[0]); end >= 0 {
+			start = len(v) - len(t) + end + 2
+		}
+	}
+
+	if i := strings.Index(v[start:], " #"); i >= 0 {
+		return v[:start+i]
+	}
+	return v
+}
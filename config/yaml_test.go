@@ -0,0 +1,30 @@
+package config
+
+import (
+	"github.com/kylelemons/go-gypsy/yaml"
+	"testing"
+)
+
+func TestValue(t *testing.T) {
+	tests := []struct {
+		scalar string
+		want   string
+	}{
+		{`D-1234`, "D-1234"},
+		{`D-1234 # the club's Discus`, "D-1234"},
+		{`"D-1234"`, "D-1234"},
+		{`'D-1234'  # quoted`, "D-1234"},
+		{`"Discus #2"`, "Discus #2"},
+		{`"Discus #2" # with a comment`, "Discus #2"},
+		{`'Discus #2'`, "Discus #2"},
+		{`Discus#2`, "Discus#2"},
+		{`O'Neill # pilot`, "O'Neill"},
+	}
+
+	for _, test := range tests {
+		v, ok := Value(yaml.Scalar(test.scalar))
+		if !ok || v != test.want {
+			t.Errorf("%s: got %q, want %q", test.scalar, v, test.want)
+		}
+	}
+}
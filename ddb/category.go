@@ -0,0 +1,83 @@
+package ddb
+
+import (
+	"fmt"
+	"github.com/masone/ogn/flarm"
+	"strings"
+)
+
+type Category string
+
+const (
+	UNKNOWN_CATEGORY Category = ""
+	GLIDER           Category = "glider"
+	MOTOR_GLIDER     Category = "tmg"
+	TOW_PLANE        Category = "towplane"
+	POWERED          Category = "powered"
+	HELICOPTER       Category = "helicopter"
+	PARAGLIDER       Category = "paraglider"
+)
+
+var categories = []Category{GLIDER, MOTOR_GLIDER, TOW_PLANE, POWERED, HELICOPTER, PARAGLIDER}
+
+func ParseCategory(s string) (Category, error) {
+	for _, c := range categories {
+		if string(c) == strings.ToLower(s) {
+			return c, nil
+		}
+	}
+	return UNKNOWN_CATEGORY, fmt.Errorf("ddb: unknown category %q", s)
+}
+
+// Parts of DDB model names hinting at the category, checked in order
+var model_categories = []struct {
+	category Category
+	names    []string
+}{
+	{MOTOR_GLIDER, []string{"falke", "dimona", "sf 25", "sf-25", "sf25", "hk 36", "hk36", "taifun", "stemme", "g 109", "g109"}},
+	{TOW_PLANE, []string{"pawnee", "husky", "dr400", "dr 400", "pa-18", "pa 18", "pa-25", "pa 25", "remorqueur"}},
+	{HELICOPTER, []string{"heli", "robinson", "r22", "r44", "r66", "ec120", "ec130", "ec135", "as350", "cabri", "bell "}},
+	{PARAGLIDER, []string{"paraglider", "gleitschirm", "hang glider", "hängegleiter"}},
+}
+
+func modelCategory(model string) Category {
+	model = strings.ToLower(model)
+	for _, mc := range model_categories {
+		for _, name := range mc.names {
+			if strings.Contains(model, name) {
+				return mc.category
+			}
+		}
+	}
+	return UNKNOWN_CATEGORY
+}
+
+// Classify tells the category of an aircraft from the local overrides, the aircraft type
+// sent by its device and its DDB model, in that order. FLARM doesn't know motor gliders
+// and tow planes are often configured as powered aircraft, the model refines those.
+func Classify(a Aircraft, t flarm.AircraftType) Category {
+	if a.Category != UNKNOWN_CATEGORY {
+		return a.Category
+	}
+
+	model := modelCategory(a.Model)
+	switch t {
+	case flarm.GLIDER:
+		if model == MOTOR_GLIDER {
+			return model
+		}
+		return GLIDER
+	case flarm.TOW_PLANE:
+		return TOW_PLANE
+	case flarm.HELICOPTER:
+		return HELICOPTER
+	case flarm.PARAGLIDER, flarm.HANG_GLIDER:
+		return PARAGLIDER
+	case flarm.POWERED, flarm.JET, flarm.DROP_PLANE:
+		if model == MOTOR_GLIDER || model == TOW_PLANE {
+			return model
+		}
+		return POWERED
+	}
+	return model
+}
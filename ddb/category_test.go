@@ -0,0 +1,42 @@
+package ddb
+
+import (
+	"github.com/masone/ogn/flarm"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name     string
+		aircraft Aircraft
+		t        flarm.AircraftType
+		want     Category
+	}{
+		{"glider", Aircraft{Model: "LS-4"}, flarm.GLIDER, GLIDER},
+		{"tmg sending glider", Aircraft{Model: "SF 25C Falke"}, flarm.GLIDER, MOTOR_GLIDER},
+		{"tow plane", Aircraft{}, flarm.TOW_PLANE, TOW_PLANE},
+		{"tow plane sending powered", Aircraft{Model: "Piper PA-25 Pawnee"}, flarm.POWERED, TOW_PLANE},
+		{"tmg sending powered", Aircraft{Model: "Grob G 109"}, flarm.POWERED, MOTOR_GLIDER},
+		{"powered", Aircraft{Model: "Cessna 172"}, flarm.POWERED, POWERED},
+		{"helicopter", Aircraft{}, flarm.HELICOPTER, HELICOPTER},
+		{"hang glider", Aircraft{}, flarm.HANG_GLIDER, PARAGLIDER},
+		{"unknown type by model", Aircraft{Model: "Robinson R44"}, flarm.UNKNOWN_AIRCRAFT, HELICOPTER},
+		{"unknown type and model", Aircraft{Model: "Discus"}, flarm.UNKNOWN_AIRCRAFT, UNKNOWN_CATEGORY},
+		{"override wins", Aircraft{Model: "Piper PA-25 Pawnee", Category: POWERED}, flarm.TOW_PLANE, POWERED},
+	}
+
+	for _, test := range tests {
+		if got := Classify(test.aircraft, test.t); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestParseCategory(t *testing.T) {
+	if c, err := ParseCategory("TowPlane"); err != nil || c != TOW_PLANE {
+		t.Errorf("got %q %v", c, err)
+	}
+	if _, err := ParseCategory("glider plane"); err == nil {
+		t.Error("want an error")
+	}
+}
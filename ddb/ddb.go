@@ -1,57 +1,326 @@
 package ddb
 
 import (
+	"bytes"
 	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/ioutil"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 type Aircraft struct {
+	DeviceType   string // F (FLARM), I (ICAO) or O (OGN tracker)
 	Id           string
 	Model        string
 	Registration string
 	Callsign     string
+	Tracked      bool // the owner allows tracking the device
+	Identified   bool // the owner allows showing the registration
+
+	// from the local overrides
+	Category Category
+	Owner    string
+	Club     bool
+}
+
+// Prefixes of the DDB device types as used in OGN callsigns, eg. FLRDDA5BA
+var device_types = map[string]string{
+	"F": "FLR",
+	"I": "ICA",
+	"O": "OGN",
+}
+
+// Key matches flarm.Device.Key, eg. FLRDDA5BA.
+func (a Aircraft) Key() string {
+	return device_types[a.DeviceType] + a.Id
+}
+
+// Anonymous labels devices whose owner doesn't want them identified. The label is
+// stable per device so that its flights can be told apart.
+func Anonymous(key string) string {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return fmt.Sprintf("anon%03X", h.Sum32()&0xFFF)
 }
 
 type AircraftList map[string]Aircraft
 
-var aircrafts AircraftList
+// cache metadata, stored next to the cache file
+type cacheInfo struct {
+	ETag       string
+	Downloaded time.Time
+}
+
+var (
+	aircrafts  AircraftList
+	downloaded time.Time
+	mutex      sync.RWMutex
+)
+
+const default_url = "http://ddb.glidernet.org/download"
 
+// Download loads the device database from DDB_FILE or DDB_URL. Downloads are cached
+// in DDB_CACHE, which is used when the DDB can't be reached.
 func Download() {
-	aircrafts = make(AircraftList)
+	set(make(AircraftList), time.Time{})
+
+	if fn := os.Getenv("DDB_FILE"); fn != "" {
+		list, err := loadFile(fn)
+		if err != nil {
+			log.Fatal(err)
+		}
+		set(merge(list), time.Time{})
+		log.Printf("Loaded %d devices from %s\n", len(list), fn)
+		return
+	}
+
+	cache := cacheFile()
+	list, err := download(ddbUrl(), cache)
+	if err == nil {
+		set(merge(list), time.Now())
+		return
+	}
+
+	log.Printf("Downloading the DDB failed: %s\n", err)
+	list, err = loadFile(cache)
+	if err != nil {
+		log.Printf("No DDB cache available, no aircraft will be identified: %s\n", err)
+		return
+	}
+	set(merge(list), readCacheInfo(cache).Downloaded)
+	log.Printf("Loaded %d devices from cache, %s old\n", len(list), CacheAge().Truncate(time.Minute))
+}
+
+// Refresh reloads the DDB and swaps it in, keeping the current one when that fails.
+func Refresh() error {
+	var list AircraftList
+	var err error
+	if fn := os.Getenv("DDB_FILE"); fn != "" {
+		list, err = loadFile(fn)
+	} else {
+		list, err = download(ddbUrl(), cacheFile())
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := LoadOverrides(); err != nil {
+		log.Printf("Keeping the previous overrides: %s\n", err)
+	}
+	list = merge(list)
+
+	mutex.RLock()
+	old := aircrafts
+	mutex.RUnlock()
+	logDiff(old, list)
+
+	set(list, time.Now())
+	return nil
+}
+
+// StartRefresh refreshes the DDB every DDB_REFRESH minutes (60 by default, 0 disables it).
+func StartRefresh() error {
+	interval := 60
+	if s := os.Getenv("DDB_REFRESH"); s != "" {
+		var err error
+		if interval, err = strconv.Atoi(s); err != nil {
+			return fmt.Errorf("ddb: invalid DDB_REFRESH %q", s)
+		}
+	}
+	if interval <= 0 {
+		return nil
+	}
+
+	go func() {
+		for range time.Tick(time.Duration(interval) * time.Minute) {
+			if err := Refresh(); err != nil {
+				log.Printf("Refreshing the DDB failed: %s\n", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// CacheAge is the time since the loaded DDB was downloaded.
+func CacheAge() time.Duration {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	if downloaded.IsZero() {
+		return 0
+	}
+	return time.Since(downloaded)
+}
+
+func set(list AircraftList, t time.Time) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	aircrafts = list
+	downloaded = t
+}
+
+func logDiff(old AircraftList, list AircraftList) {
+	var added, changed, removed int
+	for id, a := range list {
+		if o, ok := old[id]; !ok {
+			added++
+			log.Printf("DDB added %s %s (%s)\n", id, a.Registration, a.Callsign)
+		} else if o != a {
+			changed++
+			log.Printf("DDB changed %s %s (%s) -> %s (%s)\n", id, o.Registration, o.Callsign, a.Registration, a.Callsign)
+		}
+	}
+	for id, o := range old {
+		if _, ok := list[id]; !ok {
+			removed++
+			log.Printf("DDB removed %s %s (%s)\n", id, o.Registration, o.Callsign)
+		}
+	}
+	log.Printf("DDB refreshed: %d devices, %d added, %d changed, %d removed\n", len(list), added, changed, removed)
+}
+
+func ddbUrl() string {
+	if url := os.Getenv("DDB_URL"); url != "" {
+		return url
+	}
+	return default_url
+}
+
+func cacheFile() string {
+	if fn := os.Getenv("DDB_CACHE"); fn != "" {
+		return fn
+	}
+	return "ddb.csv"
+}
 
-	response, err := http.Get("http://ddb.glidernet.org/download")
+func download(url string, cache string) (AircraftList, error) {
+	request, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+
+	info := readCacheInfo(cache)
+	if info.ETag != "" {
+		request.Header.Set("If-None-Match", info.ETag)
 	}
 
+	client := http.Client{Timeout: 60 * time.Second}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
 	defer response.Body.Close()
-	parse(response)
+
+	if response.StatusCode == http.StatusNotModified {
+		list, err := loadFile(cache)
+		if err != nil {
+			return nil, err
+		}
+		writeCacheInfo(cache, cacheInfo{ETag: info.ETag, Downloaded: time.Now()})
+		log.Printf("DDB not modified, loaded %d devices from cache\n", len(list))
+		return list, nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ddb: %s responded %s", url, response.Status)
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	list, err := parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ioutil.WriteFile(cache, body, 0644); err != nil {
+		log.Printf("Caching the DDB failed: %s\n", err)
+	} else {
+		writeCacheInfo(cache, cacheInfo{ETag: response.Header.Get("ETag"), Downloaded: time.Now()})
+	}
+	log.Printf("Downloaded %d devices from %s\n", len(list), url)
+	return list, nil
+}
+
+func loadFile(fn string) (AircraftList, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parse(f)
 }
 
-func parse(response *http.Response) {
-	csv_reader := csv.NewReader(response.Body)
+func readCacheInfo(cache string) cacheInfo {
+	var info cacheInfo
+	data, err := ioutil.ReadFile(cache + ".json")
+	if err == nil {
+		json.Unmarshal(data, &info)
+	}
+	return info
+}
+
+func writeCacheInfo(cache string, info cacheInfo) {
+	data, _ := json.Marshal(info)
+	if err := ioutil.WriteFile(cache+".json", data, 0644); err != nil {
+		log.Println(err)
+	}
+}
+
+func parse(r io.Reader) (AircraftList, error) {
+	csv_reader := csv.NewReader(r)
+	csv_reader.FieldsPerRecord = -1
 	csv, err := csv_reader.ReadAll()
 
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
+	list := make(AircraftList)
 	// TODO: iterator
 	for _, line := range csv {
+		if len(line) < 5 || strings.HasPrefix(line[0], "#") {
+			continue
+		}
+
+		for i := range line {
+			line[i] = strings.Replace(line[i], "'", "", 2)
+		}
+
 		a := Aircraft{
-			Id:           strings.Replace(line[1], "'", "", 2),
-			Model:        strings.Replace(line[2], "'", "", 2),
-			Registration: strings.Replace(line[3], "'", "", 2),
-			Callsign:     strings.Replace(line[4], "'", "", 2)}
+			DeviceType:   line[0],
+			Id:           line[1],
+			Model:        line[2],
+			Registration: line[3],
+			Callsign:     line[4],
+			Tracked:      len(line) < 6 || line[5] != "N",
+			Identified:   len(line) < 7 || line[6] != "N"}
 
-		aircrafts[a.Id] = a
+		if _, ok := device_types[a.DeviceType]; !ok {
+			continue
+		}
+		list[a.Key()] = a
 	}
 
+	return list, nil
 }
 
+// GetAircraft looks up a device by its key, eg. FLRDDA5BA.
 func GetAircraft(id string) (Aircraft, bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
 	a, ok := aircrafts[id]
 	return a, ok
 }
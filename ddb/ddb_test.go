@@ -0,0 +1,36 @@
+package ddb
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const ddb_csv = `#DEVICE_TYPE,DEVICE_ID,AIRCRAFT_MODEL,REGISTRATION,CN,TRACKED,IDENTIFIED
+'F','DDA5BA','LS-4','HB-1234','AB','Y','Y'
+'I','4B50A1','Piper PA-25 Pawnee','HB-PDX','','Y','Y'
+'O','395004','Discus','D-1234','XY','N','Y'
+'F','DD1234','ASK 21','HB-5678','CD','Y','N'
+'F','DD9999','Old','HB-9999','EF'
+'X','123456','Unknown type','HB-0000','GH','Y','Y'
+'F','DD0000'
+`
+
+func TestParse(t *testing.T) {
+	list, err := parse(strings.NewReader(ddb_csv))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := AircraftList{
+		"FLRDDA5BA": {DeviceType: "F", Id: "DDA5BA", Model: "LS-4", Registration: "HB-1234", Callsign: "AB", Tracked: true, Identified: true},
+		"ICA4B50A1": {DeviceType: "I", Id: "4B50A1", Model: "Piper PA-25 Pawnee", Registration: "HB-PDX", Tracked: true, Identified: true},
+		"OGN395004": {DeviceType: "O", Id: "395004", Model: "Discus", Registration: "D-1234", Callsign: "XY", Tracked: false, Identified: true},
+		"FLRDD1234": {DeviceType: "F", Id: "DD1234", Model: "ASK 21", Registration: "HB-5678", Callsign: "CD", Tracked: true, Identified: false},
+		// older exports without the flags
+		"FLRDD9999": {DeviceType: "F", Id: "DD9999", Model: "Old", Registration: "HB-9999", Callsign: "EF", Tracked: true, Identified: true},
+	}
+	if !reflect.DeepEqual(list, want) {
+		t.Errorf("got %+v\nwant %+v", list, want)
+	}
+}
@@ -0,0 +1,206 @@
+package ddb
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/kylelemons/go-gypsy/yaml"
+	"github.com/masone/ogn/config"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var key_matcher = regexp.MustCompile(`^(FLR|ICA|OGN)([0-9A-F]{6})$`)
+
+// overrides from DDB_OVERRIDES, merged over the DDB
+var overrides AircraftList
+
+// LoadOverrides reads the local fleet from DDB_OVERRIDES, a YAML or CSV file keyed by
+// the device key (FLRDDA5BA):
+//
+//	FLRDDA5BA:
+//	  registration: HB-1234
+//	  callsign: AB
+//	  model: LS-4
+//	  category: glider
+//	  owner: SG Zürich
+//	  club: true
+//
+// The CSV needs a header with the id column and any of the other fields.
+func LoadOverrides() error {
+	fn := os.Getenv("DDB_OVERRIDES")
+	if fn == "" {
+		return nil
+	}
+
+	var list AircraftList
+	var err error
+	switch strings.ToLower(filepath.Ext(fn)) {
+	case ".csv":
+		list, err = readOverridesCsv(fn)
+	case ".yml", ".yaml":
+		list, err = readOverridesYaml(fn)
+	default:
+		err = fmt.Errorf("ddb: unsupported overrides file %s, use .yml or .csv", fn)
+	}
+	if err != nil {
+		return err
+	}
+
+	mutex.Lock()
+	overrides = list
+	mutex.Unlock()
+
+	log.Printf("Loaded %d overrides from %s\n", len(list), fn)
+	return nil
+}
+
+func readOverridesYaml(fn string) (AircraftList, error) {
+	root, err := config.ReadYaml(fn)
+	if err != nil {
+		return nil, fmt.Errorf("ddb: %s", err)
+	}
+
+	list := make(AircraftList)
+
+	for key, node := range root {
+		fields, ok := node.(yaml.Map)
+		if !ok {
+			return nil, fmt.Errorf("ddb: %s: %s: expected a map of fields", fn, key)
+		}
+
+		values := make(map[string]string)
+		for name, value := range fields {
+			v, ok := config.Value(value)
+			if !ok {
+				return nil, fmt.Errorf("ddb: %s: %s: %s is not a value", fn, key, name)
+			}
+			values[name] = v
+		}
+
+		a, err := newOverride(key, values)
+		if err != nil {
+			return nil, fmt.Errorf("ddb: %s: %s", fn, err)
+		}
+		list[key] = a
+	}
+	return list, nil
+}
+
+func readOverridesCsv(fn string) (AircraftList, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lines, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("ddb: %s: %s", fn, err)
+	}
+
+	list := make(AircraftList)
+	if len(lines) == 0 {
+		return list, nil
+	}
+
+	header := lines[0]
+	for i, line := range lines[1:] {
+		values := make(map[string]string)
+		var key string
+		for j, name := range header {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "id" {
+				key = strings.TrimSpace(line[j])
+			} else if line[j] != "" {
+				values[name] = strings.TrimSpace(line[j])
+			}
+		}
+
+		if _, ok := list[key]; ok {
+			return nil, fmt.Errorf("ddb: %s line %d: duplicate %s", fn, i+2, key)
+		}
+		a, err := newOverride(key, values)
+		if err != nil {
+			return nil, fmt.Errorf("ddb: %s line %d: %s", fn, i+2, err)
+		}
+		list[key] = a
+	}
+	return list, nil
+}
+
+func newOverride(key string, values map[string]string) (Aircraft, error) {
+	m := key_matcher.FindStringSubmatch(key)
+	if m == nil {
+		return Aircraft{}, fmt.Errorf("invalid device key %q, expected eg. FLRDDA5BA", key)
+	}
+
+	a := Aircraft{Id: m[2], Tracked: true, Identified: true}
+	for t, prefix := range device_types {
+		if prefix == m[1] {
+			a.DeviceType = t
+		}
+	}
+
+	for name, value := range values {
+		var err error
+		switch name {
+		case "registration":
+			a.Registration = value
+		case "callsign":
+			a.Callsign = value
+		case "model":
+			a.Model = value
+		case "category":
+			a.Category, err = ParseCategory(value)
+		case "owner":
+			a.Owner = value
+		case "club":
+			a.Club, err = strconv.ParseBool(value)
+		default:
+			err = fmt.Errorf("unknown field %q", name)
+		}
+		if err != nil {
+			return Aircraft{}, fmt.Errorf("%s: %s", key, err)
+		}
+	}
+	return a, nil
+}
+
+// merge applies the overrides to a freshly loaded DDB. The fields set in the overrides
+// win, but the tracking and identification wishes of the owners registered in the DDB
+// are kept. Devices missing in the DDB are added.
+func merge(list AircraftList) AircraftList {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	for key, o := range overrides {
+		a, ok := list[key]
+		if !ok {
+			list[key] = o
+			continue
+		}
+
+		if o.Registration != "" {
+			a.Registration = o.Registration
+		}
+		if o.Callsign != "" {
+			a.Callsign = o.Callsign
+		}
+		if o.Model != "" {
+			a.Model = o.Model
+		}
+		if o.Category != UNKNOWN_CATEGORY {
+			a.Category = o.Category
+		}
+		if o.Owner != "" {
+			a.Owner = o.Owner
+		}
+		a.Club = o.Club
+		list[key] = a
+	}
+	return list
+}
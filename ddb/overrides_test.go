@@ -0,0 +1,108 @@
+package ddb
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeOverrides(t *testing.T, dir string, fn string, data string) string {
+	fn = filepath.Join(dir, fn)
+	if err := ioutil.WriteFile(fn, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return fn
+}
+
+func TestReadOverrides(t *testing.T) {
+	dir, err := ioutil.TempDir("", "overrides")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	yml := writeOverrides(t, dir, "fleet.yml", `FLRDDA5BA:
+  registration: HB-1234
+  callsign: "AB" # the club's LS-4
+  category: glider
+  owner: "SG #1"
+  club: true
+ICA4B50A1:
+  category: towplane
+`)
+	csv := writeOverrides(t, dir, "fleet.csv", `id,registration,callsign,category,owner,club
+FLRDDA5BA,HB-1234,AB,glider,SG #1,true
+ICA4B50A1,,,towplane,,
+`)
+
+	want := AircraftList{
+		"FLRDDA5BA": {DeviceType: "F", Id: "DDA5BA", Registration: "HB-1234", Callsign: "AB", Category: GLIDER, Owner: "SG #1", Club: true, Tracked: true, Identified: true},
+		"ICA4B50A1": {DeviceType: "I", Id: "4B50A1", Category: TOW_PLANE, Tracked: true, Identified: true},
+	}
+	for fn, read := range map[string]func(string) (AircraftList, error){yml: readOverridesYaml, csv: readOverridesCsv} {
+		list, err := read(fn)
+		if err != nil {
+			t.Fatalf("%s: %v", fn, err)
+		}
+		if !reflect.DeepEqual(list, want) {
+			t.Errorf("%s: got %+v\nwant %+v", fn, list, want)
+		}
+	}
+}
+
+func TestReadOverridesInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "overrides")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		data string
+		want string
+	}{
+		{"DDA5BA:\n  registration: HB-1234\n", "invalid device key"},
+		{"FLRDDA5BA:\n  category: glider plane\n", "unknown category"},
+		{"FLRDDA5BA:\n  club: maybe\n", "invalid syntax"},
+		{"FLRDDA5BA:\n  colour: red\n", "unknown field"},
+		{"FLRDDA5BA: HB-1234\n", "expected a map of fields"},
+	}
+	for _, test := range tests {
+		fn := writeOverrides(t, dir, "fleet.yml", test.data)
+		if _, err := readOverridesYaml(fn); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%q: got %v, want %q", test.data, err, test.want)
+		}
+	}
+
+	fn := writeOverrides(t, dir, "fleet.csv", "id,registration\nFLRDDA5BA,HB-1234\nFLRDDA5BA,HB-5678\n")
+	if _, err := readOverridesCsv(fn); err == nil || !strings.Contains(err.Error(), "line 3: duplicate FLRDDA5BA") {
+		t.Errorf("got %v, want the duplicate", err)
+	}
+}
+
+// The overrides win over the DDB, except for the owner's tracking and identification wishes.
+func TestMerge(t *testing.T) {
+	defer func() { overrides = nil }()
+	overrides = AircraftList{
+		"FLRDDA5BA": {DeviceType: "F", Id: "DDA5BA", Registration: "HB-1234", Category: GLIDER, Club: true, Tracked: true, Identified: true},
+		"ICA4B50A1": {DeviceType: "I", Id: "4B50A1", Registration: "HB-PDX", Category: TOW_PLANE, Tracked: true, Identified: true},
+	}
+
+	list := merge(AircraftList{
+		"FLRDDA5BA": {DeviceType: "F", Id: "DDA5BA", Model: "LS-4", Registration: "HB-9999", Callsign: "AB", Tracked: false, Identified: false},
+		"FLRDD1234": {DeviceType: "F", Id: "DD1234", Model: "ASK 21", Registration: "HB-5678", Tracked: true, Identified: true},
+	})
+
+	want := AircraftList{
+		"FLRDDA5BA": {DeviceType: "F", Id: "DDA5BA", Model: "LS-4", Registration: "HB-1234", Callsign: "AB", Category: GLIDER, Club: true, Tracked: false, Identified: false},
+		"FLRDD1234": {DeviceType: "F", Id: "DD1234", Model: "ASK 21", Registration: "HB-5678", Tracked: true, Identified: true},
+		// not in the DDB
+		"ICA4B50A1": {DeviceType: "I", Id: "4B50A1", Registration: "HB-PDX", Category: TOW_PLANE, Tracked: true, Identified: true},
+	}
+	if !reflect.DeepEqual(list, want) {
+		t.Errorf("got %+v\nwant %+v", list, want)
+	}
+}
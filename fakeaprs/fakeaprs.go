@@ -0,0 +1,331 @@
+package fakeaprs
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/kellydunn/golang-geo"
+	"github.com/masone/ogn/aprs"
+	"github.com/masone/ogn/packet"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is a minimal APRS-IS server for offline testing. Every client
+// logs in, gets the lines of the Source matching its r/lat/lon/radius
+// filter and the usual server keepalives.
+type Server struct {
+	// Source is opened for the first client and read on across reconnects,
+	// like the live feed which doesn't start over either.
+	Source func() (aprs.Source, error)
+
+	Keepalive       time.Duration // between server keepalives
+	DisconnectAfter int           // drop clients after that many lines, 0 never
+	GarbageEvery    int           // send an unparseable line every that many lines, 0 never
+
+	listener net.Listener
+	wg       sync.WaitGroup
+	mutex    sync.Mutex
+	clients  map[net.Conn]bool
+	accepted int
+	closed   bool
+
+	open    sync.Once
+	source  aprs.Source
+	feed    chan string
+	pending []string // read for a client which was gone before it got them
+	done    chan struct{}
+}
+
+func NewServer(source func() (aprs.Source, error)) *Server {
+	return &Server{
+		Source:    source,
+		Keepalive: 20 * time.Second,
+		clients:   make(map[net.Conn]bool),
+		feed:      make(chan string),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start listens on addr, eg. 127.0.0.1:0 for a random port.
+func (s *Server) Start(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+
+	s.wg.Add(1)
+	go s.accept()
+	return nil
+}
+
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *Server) Close() error {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.done)
+	err := s.listener.Close()
+	for c := range s.clients {
+		c.Close()
+	}
+	source := s.source
+	s.mutex.Unlock()
+
+	s.wg.Wait()
+	if source != nil {
+		source.Close()
+	}
+	return err
+}
+
+// Connections counts the clients connected so far, reconnects included.
+func (s *Server) Connections() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.accepted
+}
+
+// DisconnectAll drops all connected clients, like a server restart.
+func (s *Server) DisconnectAll() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for c := range s.clients {
+		c.Close()
+	}
+}
+
+func (s *Server) accept() {
+	defer s.wg.Done()
+
+	for {
+		c, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mutex.Lock()
+		if s.closed {
+			s.mutex.Unlock()
+			c.Close()
+			return
+		}
+		s.clients[c] = true
+		s.accepted++
+		s.mutex.Unlock()
+
+		s.wg.Add(1)
+		go s.serve(c)
+	}
+}
+
+func (s *Server) serve(c net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mutex.Lock()
+		delete(s.clients, c)
+		s.mutex.Unlock()
+		c.Close()
+	}()
+
+	fmt.Fprintf(c, "# aprsc fake %s FAKE %s\r\n", serverTime(), c.LocalAddr())
+
+	reader := bufio.NewReader(c)
+	login, err := reader.ReadString('\n')
+	if err != nil {
+		return
+	}
+	// client keepalives are ignored
+	gone := make(chan struct{})
+	go func() {
+		io.Copy(ioutil.Discard, reader)
+		close(gone)
+	}()
+
+	user, filters := parseLogin(login)
+	fmt.Fprintf(c, "# logresp %s unverified, server FAKE\r\n", user)
+
+	if err := s.openSource(); err != nil {
+		log.Println(err)
+		return
+	}
+
+	keepalive := time.NewTicker(s.keepalive())
+	defer keepalive.Stop()
+
+	feed := s.feed
+	sent := 0
+	for {
+		line, ok := s.takePending()
+		if !ok {
+			select {
+			case <-gone:
+				return
+			case <-keepalive.C:
+				if _, err := fmt.Fprintf(c, "# aprsc fake %s FAKE %s\r\n", serverTime(), c.LocalAddr()); err != nil {
+					return
+				}
+				continue
+			case line, ok = <-feed:
+				if !ok {
+					// keep the connection open like a quiet feed
+					feed = nil
+					continue
+				}
+			}
+		}
+		if !matches(filters, line) {
+			continue
+		}
+
+		if _, err := fmt.Fprintf(c, "%s\r\n", strings.TrimRight(line, "\r\n")); err != nil {
+			// the next client gets it
+			s.putPending(line)
+			return
+		}
+		sent++
+
+		if s.GarbageEvery > 0 && sent%s.GarbageEvery == 0 {
+			fmt.Fprint(c, "FLRGARBAGE>APRS,qAS,FAKE:/garbage\r\n")
+		}
+		if s.DisconnectAfter > 0 && sent >= s.DisconnectAfter {
+			return
+		}
+	}
+}
+
+// openSource starts reading the Source into the feed shared by all clients.
+func (s *Server) openSource() error {
+	var err error
+	s.open.Do(func() {
+		var source aprs.Source
+		if source, err = s.Source(); err != nil {
+			close(s.feed)
+			return
+		}
+
+		s.mutex.Lock()
+		s.source = source
+		s.mutex.Unlock()
+		go s.read(source)
+	})
+	return err
+}
+
+func (s *Server) read(source aprs.Source) {
+	defer close(s.feed)
+
+	for {
+		line, err := source.ReadLine()
+		if line != "" {
+			select {
+			case s.feed <- line:
+			case <-s.done:
+				return
+			}
+		}
+		if err != nil {
+			if err != io.EOF {
+				log.Println(err)
+			}
+			return
+		}
+	}
+}
+
+func (s *Server) takePending() (string, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.pending) == 0 {
+		return "", false
+	}
+	line := s.pending[0]
+	s.pending = s.pending[1:]
+	return line, true
+}
+
+func (s *Server) putPending(line string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.pending = append(s.pending, line)
+}
+
+func (s *Server) keepalive() time.Duration {
+	if s.Keepalive <= 0 {
+		return 20 * time.Second
+	}
+	return s.Keepalive
+}
+
+func serverTime() string {
+	return time.Now().UTC().Format("2 Jan 2006 15:04:05 GMT")
+}
+
+type rangeFilter struct {
+	center *geo.Point
+	radius float64 // in km
+}
+
+// user OGN123 pass -1 vers ogn 0.0.1 filter r/46.8/8.3/100 r/47.1/8.5/50
+func parseLogin(login string) (string, []rangeFilter) {
+	items := strings.Fields(login)
+
+	var user string
+	var filters []rangeFilter
+	for i, item := range items {
+		if item == "user" && i+1 < len(items) {
+			user = items[i+1]
+		}
+		if !strings.HasPrefix(item, "r/") {
+			continue
+		}
+
+		parts := strings.Split(item, "/")
+		if len(parts) != 4 {
+			continue
+		}
+		lat, err1 := strconv.ParseFloat(parts[1], 64)
+		lon, err2 := strconv.ParseFloat(parts[2], 64)
+		radius, err3 := strconv.ParseFloat(parts[3], 64)
+		if err1 == nil && err2 == nil && err3 == nil {
+			filters = append(filters, rangeFilter{center: geo.NewPoint(lat, lon), radius: radius})
+		}
+	}
+	return user, filters
+}
+
+// Without filters, everything is sent. Lines without position pass.
+func matches(filters []rangeFilter, line string) bool {
+	if len(filters) == 0 || strings.HasPrefix(line, "#") {
+		return true
+	}
+
+	p, err := packet.Parse(line)
+	if err != nil || p.Type != packet.LOCATION {
+		return true
+	}
+
+	point := geo.NewPoint(p.Latitude, p.Longitude)
+	for _, f := range filters {
+		if f.center.GreatCircleDistance(point) <= f.radius {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package flarm
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type AddressType int
+
+const (
+	UNKNOWN_ADDRESS AddressType = -1
+	RANDOM          AddressType = 0
+	ICAO            AddressType = 1
+	FLARM           AddressType = 2
+	OGN             AddressType = 3
+)
+
+// Prefixes as used in OGN callsigns, eg. FLRDDA5BA
+var address_prefixes = map[AddressType]string{
+	RANDOM: "RND",
+	ICAO:   "ICA",
+	FLARM:  "FLR",
+	OGN:    "OGN",
+}
+
+func (t AddressType) String() string {
+	switch t {
+	case RANDOM:
+		return "random"
+	case ICAO:
+		return "ICAO"
+	case FLARM:
+		return "FLARM"
+	case OGN:
+		return "OGN"
+	default:
+		return "unknown"
+	}
+}
+
+type AircraftType int
+
+const (
+	UNKNOWN_AIRCRAFT AircraftType = iota
+	GLIDER                        // or motor glider
+	TOW_PLANE
+	HELICOPTER
+	SKYDIVER
+	DROP_PLANE
+	HANG_GLIDER
+	PARAGLIDER
+	POWERED
+	JET
+	UFO
+	BALLOON
+	AIRSHIP
+	UAV
+	GROUND_SUPPORT
+	STATIC_OBJECT
+)
+
+var aircraft_types = []string{
+	"unknown", "glider", "tow plane", "helicopter", "skydiver", "drop plane", "hang glider", "paraglider",
+	"powered aircraft", "jet", "UFO", "balloon", "airship", "UAV", "ground support", "static object",
+}
+
+func (t AircraftType) String() string {
+	if t < 0 || int(t) >= len(aircraft_types) {
+		return "unknown"
+	}
+	return aircraft_types[t]
+}
+
+// Device is decoded from the id token: idXXYYYYYY
+// XX: stealth (1 bit), no-track (1 bit), aircraft type (4 bits), address type (2 bits)
+// YYYYYY: address
+type Device struct {
+	Id           string
+	AddressType  AddressType
+	AircraftType AircraftType
+	Stealth      bool
+	NoTrack      bool
+}
+
+var id_matcher = regexp.MustCompile(`^id([0-9A-Fa-f]{2})([0-9A-Fa-f]{6})$`)
+
+func ParseId(s string) (Device, error) {
+	m := id_matcher.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return Device{}, fmt.Errorf("flarm: invalid id %q", s)
+	}
+
+	flags, _ := strconv.ParseUint(m[1], 16, 8)
+	return Device{
+		Id:           strings.ToUpper(m[2]),
+		AddressType:  AddressType(flags & 0x03),
+		AircraftType: AircraftType((flags >> 2) & 0x0F),
+		NoTrack:      flags&0x40 != 0,
+		Stealth:      flags&0x80 != 0,
+	}, nil
+}
+
+// Key identifies the device across address types, eg. FLRDDA5BA.
+func (d Device) Key() string {
+	prefix, ok := address_prefixes[d.AddressType]
+	if !ok {
+		return d.Id
+	}
+	return prefix + d.Id
+}
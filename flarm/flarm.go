@@ -1,45 +1,131 @@
 package flarm
 
 import (
+	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
 )
 
 type Comment struct {
-	Id             string
-	SignalStrength string
-	Frequency      string
-	Rot            string
-	ClimbRate      float64
-	Fpm            string
-	Errors         string
-}
-
-// example comment: id02DF0A52 -019fpm +0.0rot 55.2dB 0e -9.9kHz gps3x6
-func ParseComment(c string) Comment {
-	items := strings.Split(strings.TrimSpace(c), " ")
-
-	comment := Comment{
-		Id:             extractId(items[0]),
-		Fpm:            items[1],
-		Rot:            items[2],
-		ClimbRate:      extractClimbRate(items[2]),
-		SignalStrength: items[3],
-		Errors:         items[4],
-		Frequency:      items[5]}
+	Device
+	ClimbRate      float64 // in m/s
+	TurnRate       float64 // in rot, 1.0rot = 3°/s
+	SignalStrength float64 // SNR in dB
+	Errors         int     // corrected bit errors
+	Frequency      float64 // offset in kHz
+	Power          float64 // transmit power in dBm
+	GpsHorizontal  int     // accuracy in meters
+	GpsVertical    int     // accuracy in meters
+	FlightLevel    float64
+	Software       string
+	Hardware       string
+	HeardFrom      []string // devices the sender received
+	RealAddress    string   // the ICAO address of a device sending a random or FLARM id
+	Extra          []string // tokens not known to the parser, eg. of newer firmware
+}
+
+type token struct {
+	matcher *regexp.Regexp
+	apply   func(c *Comment, m []string)
+}
+
+const fpm_to_ms = 0.00508
+
+var tokens = []token{
+	{regexp.MustCompile(`^([+-]\d+)fpm$`), func(c *Comment, m []string) {
+		c.ClimbRate = parseFloat(m[1]) * fpm_to_ms
+	}},
+	{regexp.MustCompile(`^([+-]?\d+(?:\.\d+)?)rot$`), func(c *Comment, m []string) {
+		c.TurnRate = parseFloat(m[1])
+	}},
+	{regexp.MustCompile(`^([+-]?\d+(?:\.\d+)?)dB$`), func(c *Comment, m []string) {
+		c.SignalStrength = parseFloat(m[1])
+	}},
+	{regexp.MustCompile(`^([+-]?\d+(?:\.\d+)?)dBm$`), func(c *Comment, m []string) {
+		c.Power = parseFloat(m[1])
+	}},
+	{regexp.MustCompile(`^(\d+)e$`), func(c *Comment, m []string) {
+		c.Errors, _ = strconv.Atoi(m[1])
+	}},
+	{regexp.MustCompile(`^([+-]?\d+(?:\.\d+)?)kHz$`), func(c *Comment, m []string) {
+		c.Frequency = parseFloat(m[1])
+	}},
+	{regexp.MustCompile(`^gps(\d+)x(\d+)$`), func(c *Comment, m []string) {
+		c.GpsHorizontal, _ = strconv.Atoi(m[1])
+		c.GpsVertical, _ = strconv.Atoi(m[2])
+	}},
+	{regexp.MustCompile(`^FL(\d+(?:\.\d+)?)$`), func(c *Comment, m []string) {
+		c.FlightLevel = parseFloat(m[1])
+	}},
+	{regexp.MustCompile(`^s(\d+(?:\.\d+)?)$`), func(c *Comment, m []string) {
+		c.Software = m[1]
+	}},
+	{regexp.MustCompile(`^h([0-9A-Fa-f]{2})$`), func(c *Comment, m []string) {
+		c.Hardware = m[1]
+	}},
+	{regexp.MustCompile(`^hear([0-9A-Fa-f]{4})$`), func(c *Comment, m []string) {
+		c.HeardFrom = append(c.HeardFrom, strings.ToUpper(m[1]))
+	}},
+	{regexp.MustCompile(`^r([0-9A-Fa-f]{6})$`), func(c *Comment, m []string) {
+		c.RealAddress = strings.ToUpper(m[1])
+	}},
+}
 
+// example comment: id02DF0A52 -019fpm +0.0rot 55.2dB 0e -9.9kHz gps3x6 s6.01 h03 rDDA5BA hear1234
+// The order of the tokens doesn't matter. A valid id is required, unknown tokens are kept in Extra.
+func ParseComment(c string) (Comment, error) {
+	comment, err := parse(c)
+	if err != nil {
+		return Comment{}, err
+	}
+	if comment.Id == "" {
+		return Comment{}, fmt.Errorf("flarm: missing id in %q", c)
+	}
+	return comment, nil
+}
+
+// ParseCommentLenient doesn't require an id and skips an invalid one,
+// for the comment dialects of other devices.
+func ParseCommentLenient(c string) Comment {
+	comment, _ := parse(c)
 	return comment
 }
 
-func extractId(s string) string {
-	id_matcher := regexp.MustCompile(`id\w{2}(\w+)`)
-	return strings.TrimSpace(id_matcher.FindStringSubmatch(s)[1])
+// parse fails on an invalid id token only.
+func parse(c string) (Comment, error) {
+	comment := Comment{}
+	var err error
+
+	for _, item := range strings.Fields(c) {
+		if strings.HasPrefix(item, "id") {
+			d, derr := ParseId(item)
+			if derr == nil {
+				comment.Device = d
+				continue
+			}
+			if err == nil {
+				err = derr
+			}
+		}
+		if !parseToken(&comment, item) {
+			comment.Extra = append(comment.Extra, item)
+		}
+	}
+	return comment, err
+}
+
+func parseToken(c *Comment, item string) bool {
+	for _, t := range tokens {
+		if m := t.matcher.FindStringSubmatch(item); m != nil {
+			t.apply(c, m)
+			return true
+		}
+	}
+	return false
 }
 
-func extractClimbRate(s string) float64 {
-	climb_rate_matcher := regexp.MustCompile(`([+-]\d+\.\d+)rot`)
-	climb_rate_str := climb_rate_matcher.FindStringSubmatch(s)[1]
-	climb_rate, _ := strconv.ParseFloat(climb_rate_str, 64)
-	return climb_rate
+func parseFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
 }
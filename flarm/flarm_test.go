@@ -0,0 +1,86 @@
+package flarm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseComment(t *testing.T) {
+	tests := []struct {
+		comment string
+		want    Comment
+	}{
+		{
+			comment: "id06DDA5BA -454fpm -1.1rot 8.8dB 0e +51.2kHz gps4x5",
+			want: Comment{
+				Device:         Device{Id: "DDA5BA", AddressType: FLARM, AircraftType: GLIDER},
+				ClimbRate:      fpm(-454),
+				TurnRate:       -1.1,
+				SignalStrength: 8.8,
+				Frequency:      51.2,
+				GpsHorizontal:  4,
+				GpsVertical:    5,
+			},
+		},
+		{
+			// current FLARM firmware sends the real address
+			comment: "id0ADDA5BA +059fpm +0.0rot 12.0dB 1e -3.4kHz gps2x3 s7.04 h44 r4B50A1 hear1234 hearABCD",
+			want: Comment{
+				Device:         Device{Id: "DDA5BA", AddressType: FLARM, AircraftType: TOW_PLANE},
+				ClimbRate:      fpm(59),
+				SignalStrength: 12,
+				Errors:         1,
+				Frequency:      -3.4,
+				GpsHorizontal:  2,
+				GpsVertical:    3,
+				Software:       "7.04",
+				Hardware:       "44",
+				RealAddress:    "4B50A1",
+				HeardFrom:      []string{"1234", "ABCD"},
+			},
+		},
+		{
+			comment: "!W52! id07395004 +020fpm +0.0rot FL011.81 55.2dB 0e -6.2kHz gps4x6 +3.5dBm",
+			want: Comment{
+				Device:         Device{Id: "395004", AddressType: OGN, AircraftType: GLIDER},
+				ClimbRate:      fpm(20),
+				FlightLevel:    11.81,
+				SignalStrength: 55.2,
+				Frequency:      -6.2,
+				GpsHorizontal:  4,
+				GpsVertical:    6,
+				Power:          3.5,
+				Extra:          []string{"!W52!"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		c, err := ParseComment(test.comment)
+		if err != nil {
+			t.Errorf("%s: %v", test.comment, err)
+			continue
+		}
+		if !reflect.DeepEqual(c, test.want) {
+			t.Errorf("%s:\ngot  %+v\nwant %+v", test.comment, c, test.want)
+		}
+	}
+}
+
+func TestParseCommentInvalid(t *testing.T) {
+	for _, comment := range []string{
+		"",
+		"-454fpm -1.1rot",
+		"idXYDDA5BA -454fpm",
+		"id06DDA5 -454fpm",
+	} {
+		if c, err := ParseComment(comment); err == nil {
+			t.Errorf("%q: got %+v, want an error", comment, c)
+		}
+	}
+}
+
+// as the parser computes it, constants would be folded exactly
+func fpm(v float64) float64 {
+	return v * fpm_to_ms
+}
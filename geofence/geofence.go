@@ -0,0 +1,123 @@
+package geofence
+
+import (
+	"errors"
+	"fmt"
+	"github.com/kellydunn/golang-geo"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Area is a part of the airfield, eg. a runway, grass strip or parking.
+type Area struct {
+	Name  string
+	outer *geo.Polygon
+	holes []*geo.Polygon
+}
+
+func (a *Area) Contains(p *geo.Point) bool {
+	if !a.outer.Contains(p) {
+		return false
+	}
+	for _, h := range a.holes {
+		if h.Contains(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// Fence is the ground of an airfield made of one or more areas.
+type Fence struct {
+	Areas []*Area
+}
+
+// Load reads the polygons of a GeoJSON (.geojson, .json) or KML (.kml) file.
+func Load(fn string) (*Fence, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var fence *Fence
+	switch strings.ToLower(filepath.Ext(fn)) {
+	case ".geojson", ".json":
+		fence, err = parseGeoJson(f)
+	case ".kml":
+		fence, err = parseKml(f)
+	default:
+		err = errors.New("unsupported format, use .geojson or .kml")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("geofence: %s: %s", fn, err)
+	}
+	if len(fence.Areas) == 0 {
+		return nil, fmt.Errorf("geofence: %s: no polygons found", fn)
+	}
+	return fence, nil
+}
+
+func (f *Fence) Contains(lat float64, lon float64) bool {
+	p := geo.NewPoint(lat, lon)
+	for _, a := range f.Areas {
+		if a.Contains(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Center is the middle of the bounding box of all areas.
+func (f *Fence) Center() *geo.Point {
+	first := true
+	var min_lat, min_lng, max_lat, max_lng float64
+	for _, a := range f.Areas {
+		for _, p := range a.outer.Points() {
+			if first || p.Lat() < min_lat {
+				min_lat = p.Lat()
+			}
+			if first || p.Lat() > max_lat {
+				max_lat = p.Lat()
+			}
+			if first || p.Lng() < min_lng {
+				min_lng = p.Lng()
+			}
+			if first || p.Lng() > max_lng {
+				max_lng = p.Lng()
+			}
+			first = false
+		}
+	}
+	return geo.NewPoint((min_lat+max_lat)/2, (min_lng+max_lng)/2)
+}
+
+// rings are lists of lng,lat pairs like in GeoJSON and KML, the first one is the outline
+func newArea(name string, rings [][][]float64) (*Area, error) {
+	if len(rings) == 0 {
+		return nil, fmt.Errorf("polygon %q without coordinates", name)
+	}
+
+	a := &Area{Name: name}
+	for i, ring := range rings {
+		if len(ring) < 3 {
+			return nil, fmt.Errorf("polygon %q needs at least 3 points", name)
+		}
+
+		polygon := geo.NewPolygon(nil)
+		for _, c := range ring {
+			if len(c) < 2 || c[1] < -90 || c[1] > 90 || c[0] < -180 || c[0] > 180 {
+				return nil, fmt.Errorf("polygon %q has an invalid coordinate %v", name, c)
+			}
+			polygon.Add(geo.NewPoint(c[1], c[0]))
+		}
+
+		if i == 0 {
+			a.outer = polygon
+		} else {
+			a.holes = append(a.holes, polygon)
+		}
+	}
+	return a, nil
+}
@@ -0,0 +1,72 @@
+package geofence
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	for _, fn := range []string{"testdata/field.geojson", "testdata/field.kml"} {
+		fence, err := Load(fn)
+		if err != nil {
+			t.Fatalf("%s: %v", fn, err)
+		}
+
+		if len(fence.Areas) != 2 {
+			t.Fatalf("%s: got %d areas, want 2", fn, len(fence.Areas))
+		}
+		if a := fence.Areas[0]; a.Name != "grass strip" || len(a.holes) != 1 {
+			t.Errorf("%s: got %q with %d holes", fn, a.Name, len(a.holes))
+		}
+		if a := fence.Areas[1]; a.Name != "parking" || len(a.holes) != 0 {
+			t.Errorf("%s: got %q with %d holes", fn, a.Name, len(a.holes))
+		}
+
+		c := fence.Center()
+		if c.Lat() != 47.175 || c.Lng() != 8.3175 {
+			t.Errorf("%s: got center %g,%g", fn, c.Lat(), c.Lng())
+		}
+	}
+}
+
+func TestContains(t *testing.T) {
+	fence, err := Load("testdata/field.geojson")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		lat, lon float64
+		want     bool
+	}{
+		{"on the strip", 47.172, 8.302, true},
+		{"in the hole", 47.175, 8.310, false},
+		{"at the windsock", 47.181, 8.310, false},
+		{"on the parking", 47.1705, 8.333, true},
+		{"between the areas", 47.175, 8.325, false},
+	}
+	for _, test := range tests {
+		if got := fence.Contains(test.lat, test.lon); got != test.want {
+			t.Errorf("%s: got %t, want %t", test.name, got, test.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, s := range []string{
+		`{"type": "Polygon", "coordinates": [[[8.3, 47.1], [8.4, 47.1]]]}`,
+		`{"type": "Polygon", "coordinates": [[[8.3, 97.1], [8.4, 47.1], [8.4, 47.2]]]}`,
+		`{"type": "Polygon", "coordinates": "x"}`,
+		`{"type": `,
+	} {
+		if _, err := parseGeoJson(strings.NewReader(s)); err == nil {
+			t.Errorf("%s: want an error", s)
+		}
+	}
+
+	kml := `<kml><Placemark><Polygon><outerBoundaryIs><LinearRing><coordinates>8.3,47.1 x,y 8.4,47.2</coordinates></LinearRing></outerBoundaryIs></Polygon></Placemark></kml>`
+	if _, err := parseKml(strings.NewReader(kml)); err == nil {
+		t.Errorf("%s: want an error", kml)
+	}
+}
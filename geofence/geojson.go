@@ -0,0 +1,78 @@
+package geofence
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+type geoJson struct {
+	Type        string                 `json:"type"`
+	Features    []geoJson              `json:"features"`
+	Geometry    *geoJson               `json:"geometry"`
+	Geometries  []geoJson              `json:"geometries"`
+	Properties  map[string]interface{} `json:"properties"`
+	Coordinates json.RawMessage        `json:"coordinates"`
+}
+
+// Polygons and multi polygons are read from feature collections, features and geometries,
+// other geometries like the points of a windsock are skipped.
+func parseGeoJson(r io.Reader) (*Fence, error) {
+	var g geoJson
+	if err := json.NewDecoder(r).Decode(&g); err != nil {
+		return nil, err
+	}
+
+	fence := &Fence{}
+	if err := fence.addGeoJson(g, ""); err != nil {
+		return nil, err
+	}
+	return fence, nil
+}
+
+func (f *Fence) addGeoJson(g geoJson, name string) error {
+	switch g.Type {
+	case "FeatureCollection":
+		for _, feature := range g.Features {
+			if err := f.addGeoJson(feature, ""); err != nil {
+				return err
+			}
+		}
+	case "Feature":
+		if n, ok := g.Properties["name"].(string); ok {
+			name = n
+		}
+		if g.Geometry != nil {
+			return f.addGeoJson(*g.Geometry, name)
+		}
+	case "GeometryCollection":
+		for _, geometry := range g.Geometries {
+			if err := f.addGeoJson(geometry, name); err != nil {
+				return err
+			}
+		}
+	case "Polygon":
+		var rings [][][]float64
+		if err := json.Unmarshal(g.Coordinates, &rings); err != nil {
+			return fmt.Errorf("invalid polygon %q: %s", name, err)
+		}
+		a, err := newArea(name, rings)
+		if err != nil {
+			return err
+		}
+		f.Areas = append(f.Areas, a)
+	case "MultiPolygon":
+		var polygons [][][][]float64
+		if err := json.Unmarshal(g.Coordinates, &polygons); err != nil {
+			return fmt.Errorf("invalid multi polygon %q: %s", name, err)
+		}
+		for _, rings := range polygons {
+			a, err := newArea(name, rings)
+			if err != nil {
+				return err
+			}
+			f.Areas = append(f.Areas, a)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package geofence
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type kmlPolygon struct {
+	Outer string   `xml:"outerBoundaryIs>LinearRing>coordinates"`
+	Inner []string `xml:"innerBoundaryIs>LinearRing>coordinates"`
+}
+
+type kmlPlacemark struct {
+	Name     string       `xml:"name"`
+	Polygons []kmlPolygon `xml:"Polygon"`
+	Multi    []kmlPolygon `xml:"MultiGeometry>Polygon"`
+}
+
+// The polygons of all placemarks, at any depth of documents and folders.
+func parseKml(r io.Reader) (*Fence, error) {
+	fence := &Fence{}
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return fence, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "Placemark" {
+			continue
+		}
+
+		var p kmlPlacemark
+		if err := decoder.DecodeElement(&p, &start); err != nil {
+			return nil, err
+		}
+		for _, polygon := range append(p.Polygons, p.Multi...) {
+			var rings [][][]float64
+			for _, s := range append([]string{polygon.Outer}, polygon.Inner...) {
+				ring, err := parseKmlCoordinates(s)
+				if err != nil {
+					return nil, fmt.Errorf("polygon %q: %s", p.Name, err)
+				}
+				rings = append(rings, ring)
+			}
+
+			a, err := newArea(strings.TrimSpace(p.Name), rings)
+			if err != nil {
+				return nil, err
+			}
+			fence.Areas = append(fence.Areas, a)
+		}
+	}
+}
+
+// lng,lat[,alt] tuples separated by whitespace
+func parseKmlCoordinates(s string) ([][]float64, error) {
+	var ring [][]float64
+	for _, tuple := range strings.Fields(s) {
+		var c []float64
+		for _, v := range strings.Split(tuple, ",") {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid coordinate %q", tuple)
+			}
+			c = append(c, f)
+		}
+		ring = append(ring, c)
+	}
+	return ring, nil
+}
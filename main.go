@@ -1,45 +1,217 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
-	"github.com/martinhpedersen/libfap-go"
 	"github.com/masone/ogn/aprs"
+	"github.com/masone/ogn/beacon"
+	"github.com/masone/ogn/clock"
 	"github.com/masone/ogn/config"
 	"github.com/masone/ogn/ddb"
 	"github.com/masone/ogn/flarm"
+	"github.com/masone/ogn/packet"
+	"github.com/masone/ogn/receivers"
 	"github.com/masone/ogn/startlist"
+	"log"
+	"time"
 )
 
 type Beacon struct {
-	*fap.Packet
+	*beacon.Position
 	ddb.Aircraft
-	flarm.Comment
 }
 
+var dispatcher = &beacon.Dispatcher{Aircraft: process_position, Receiver: receivers.Process}
+
+// simulated when replaying logfiles
+var clk clock.Clock = clock.Real{}
+
+var (
+	speed    = flag.Float64("speed", 0, "replay speed factor for logfiles, 0 replays as fast as possible")
+	date     = flag.String("date", "", "UTC date of the logfile (2015-08-28), inferred from the log or file name if empty")
+	receiver = flag.String("receiver", "", "only replay beacons relayed by these receivers (LSPH,LSZF)")
+	bbox     = flag.String("bbox", "", "only replay beacons within minLat,minLon,maxLat,maxLon")
+	airfield = flag.String("airfield", "", "only print the flights of this airfield (name in its profile)")
+)
+
 func main() {
-	config.Load()
+	flag.Parse()
+	if err := config.Load(); err != nil {
+		log.Fatal(err)
+	}
+	if err := ddb.LoadOverrides(); err != nil {
+		log.Fatal(err)
+	}
 	ddb.Download()
+	if err := ddb.StartRefresh(); err != nil {
+		log.Fatal(err)
+	}
 	startlist.Init()
-	aprs.Listen(process_message)
+	if err := startlist.PrintOnly(*airfield); err != nil {
+		log.Fatal(err)
+	}
+	receivers.Init()
+
+	deadLetter, err := aprs.NewDeadLetterFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if deadLetter != nil {
+		defer deadLetter.Close()
+		aprs.SetDeadLetter(deadLetter)
+	}
+	// log.Fatal skips the deferred Close
+	fatal := func(err error) {
+		if deadLetter != nil {
+			deadLetter.Close()
+		}
+		log.Fatal(err)
+	}
+
+	source, err := newSource()
+	if err != nil {
+		fatal(err)
+	}
+
+	monitor, err := receivers.NewMonitorFromEnv()
+	if err != nil {
+		fatal(err)
+	}
+	// replays of old logs must not alert about historical outages
+	if monitor != nil && flag.NArg() < 1 {
+		monitor.Start(clk)
+	}
+
+	if err := aprs.Listen(context.Background(), source, process_message); err != nil {
+		fatal(err)
+	}
+	// the startlist of the replayed logfiles
+	startlist.PrintStartlist(*airfield)
 }
 
-// packet: https://github.com/martinhpedersen/libfap-go/blob/832c8336185c0a6de6b792ad1531a30eac09398d/packet.go
-func process_message(p *fap.Packet) {
-	c := flarm.ParseComment(p.Comment)
-	a, ok := ddb.GetAircraft(c.Id)
-	var b Beacon
+// ./ogn reads from APRS-IS, ./ogn file.log [file.log.gz ...] from logfiles and ./ogn - from stdin
+func newSource() (aprs.Source, error) {
+	if flag.NArg() < 1 {
+		return newServerSource()
+	}
 
-	if ok {
-		b = Beacon{Packet: p, Comment: c, Aircraft: a}
-		if b.Comment.Id != "" {
-			cs := fmt.Sprintf("%7s (%2s)", b.Aircraft.Registration, b.Aircraft.Callsign)
-			startlist.ProcessEntry(b.Packet.Timestamp, b.Comment.Id, cs, b.Packet.Latitude, b.Packet.Longitude, b.Packet.Altitude, b.Comment.ClimbRate)
-		}
+	var source aprs.Source
+	var files *aprs.FilesSource
+	if flag.Arg(0) == "-" {
+		source = aprs.NewStdinSource()
 	} else {
-		b = Beacon{Packet: p, Comment: c}
+		var err error
+		if files, err = aprs.NewFilesSource(flag.Args()...); err != nil {
+			return nil, err
+		}
+		source = files
+	}
+
+	source, err := newFilter(source)
+	if err != nil {
+		return nil, err
+	}
+
+	c := clock.NewSimulated(time.Time{})
+	clk = c
+	startlist.SetClock(c)
+	replay := aprs.Replay(source, *speed, c)
+
+	if *date != "" {
+		d, err := time.Parse("2006-01-02", *date)
+		if err != nil {
+			return nil, err
+		}
+		replay.SetDate(d, true)
+	} else if files != nil {
+		// each file of a multi-day replay starts on its own date
+		files.OnOpen = func(fn string) {
+			if d, ok := aprs.DateFromFilename(fn); ok {
+				replay.SetDate(d, false)
+			}
+		}
+	}
+	return replay, nil
+}
+
+func newFilter(source aprs.Source) (aprs.Source, error) {
+	if *receiver == "" && *bbox == "" {
+		return source, nil
+	}
+
+	list := config.List(*receiver)
+
+	var box *aprs.BoundingBox
+	if *bbox != "" {
+		var err error
+		if box, err = aprs.ParseBoundingBox(*bbox); err != nil {
+			return nil, err
+		}
+	}
+	return aprs.Filter(source, list, box), nil
+}
+
+func newServerSource() (aprs.Source, error) {
+	source, err := aprs.NewServerSource()
+	if err != nil {
+		return nil, err
+	}
+
+	recorder, err := aprs.NewRecorderFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if recorder == nil {
+		return source, nil
+	}
+	return aprs.Record(source, recorder), nil
+}
+
+func process_message(p *packet.Packet) error {
+	return dispatcher.Dispatch(p)
+}
+
+func process_position(pos *beacon.Position) error {
+	// the pilot opted out of tracking
+	if pos.NoTrack {
+		return nil
+	}
+	receivers.Relayed(pos.Receiver, pos.Timestamp)
+
+	a, ok := ddb.GetAircraft(pos.Comment.Key())
+	// the owner opted out of tracking in the DDB
+	if ok && !a.Tracked {
+		return nil
+	}
+
+	b := Beacon{Position: pos, Aircraft: a}
+	if b.Comment.Id != "" {
+		var cs string
+		// stealth aircraft keep a label per device, so their flights can be told apart
+		if b.Stealth {
+			cs = fmt.Sprintf("%7s (%2s)", ddb.Anonymous(b.Comment.Key()), "")
+		} else if !ok {
+			cs = fmt.Sprintf("%7s (%2s)", unknownLabel(pos), "")
+		} else if !b.Aircraft.Identified {
+			cs = fmt.Sprintf("%7s (%2s)", ddb.Anonymous(b.Comment.Key()), "")
+		} else {
+			cs = fmt.Sprintf("%7s (%2s)", b.Aircraft.Registration, b.Aircraft.Callsign)
+		}
+		startlist.ProcessEntry(b.Packet.Timestamp, b.Comment.Key(), cs, b.Packet.Latitude, b.Packet.Longitude, b.Packet.Altitude, b.Comment.ClimbRate, b.Comment.TurnRate, b.Receiver, !ok, ddb.Classify(a, pos.AircraftType))
 	}
 
 	//fmt.Printf("%+v", b)
+	return nil
+}
+
+// Devices not in the DDB are listed by their address. Random addresses change
+// with every flight and callsigns may be too long, those are anonymised.
+func unknownLabel(pos *beacon.Position) string {
+	if pos.AddressType == flarm.RANDOM || len(pos.Comment.Id) > 7 {
+		return ddb.Anonymous(pos.Comment.Key())
+	}
+	return pos.Comment.Id
 }
 
 func (b Beacon) String() string {
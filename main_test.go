@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/masone/ogn/aprs"
+	"github.com/masone/ogn/beacon"
+	"github.com/masone/ogn/config"
+	"github.com/masone/ogn/fakeaprs"
+	"github.com/masone/ogn/startlist"
+	"github.com/masone/ogn/startlist_db"
+	"os"
+	"testing"
+	"time"
+)
+
+// A glider on the ground at Buttwil, then 3.5km away and 500m up, then back on the ground.
+func recordedFlight(start time.Time) []string {
+	var lines []string
+	for i := 0; i < 20; i++ {
+		lat, feet := "4710.72N", 2346
+		if i >= 4 && i < 16 {
+			lat, feet = "4712.60N", 3937
+		}
+		t := start.Add(time.Duration(i) * 30 * time.Second)
+		lines = append(lines, fmt.Sprintf("FLRDDA5BA>OGFLR,qAS,LSPH:/%sh%s/00818.57E'342/049/A=%06d id06DDA5BA +000fpm +0.0rot 12.0dB r4B50A1",
+			t.Format("150405"), lat, feet))
+	}
+	return lines
+}
+
+// The pipeline from the APRS-IS socket to the startlist against the fake server,
+// which drops the connection every 8 lines and sends garbage after the 5th of each connection.
+// The starts and landings are only checked with a database in DATABASE_URL.
+func TestPipeline(t *testing.T) {
+	start := time.Now().UTC().Add(-15 * time.Minute).Truncate(time.Second)
+	lines := recordedFlight(start)
+
+	server := fakeaprs.NewServer(func() (aprs.Source, error) {
+		return aprs.NewSliceSource(lines), nil
+	})
+	server.DisconnectAfter = 8
+	server.GarbageEvery = 5
+	if err := server.Start("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	for key, v := range map[string]string{
+		"APRS_SERVERS": server.Addr(),
+		"APRS_USER":    "OGNTEST",
+		"APRS_RADIUS":  "50",
+		"AF_PROFILE":   "",
+		"AF_LAT":       "47.1786",
+		"AF_LNG":       "8.3095",
+		"AF_ELEVATION": "715",
+	} {
+		defer os.Setenv(key, os.Getenv(key))
+		os.Setenv(key, v)
+	}
+	if err := config.LoadAirfields(); err != nil {
+		t.Fatal(err)
+	}
+
+	database := os.Getenv("DATABASE_URL") != ""
+	if database {
+		startlist.Init()
+	}
+
+	source, err := aprs.NewServerSource()
+	if err != nil {
+		t.Fatal(err)
+	}
+	source.MinBackoff = 10 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var positions []*beacon.Position
+	d := &beacon.Dispatcher{Aircraft: func(pos *beacon.Position) error {
+		positions = append(positions, pos)
+		if database {
+			if err := process_position(pos); err != nil {
+				return err
+			}
+		}
+		if len(positions) == len(lines) {
+			cancel()
+		}
+		return nil
+	}}
+
+	before := aprs.CurrentStats()
+	if err := aprs.Listen(ctx, source, d.Dispatch); err != context.Canceled {
+		t.Fatalf("got %v after %d positions, want all %d", err, len(positions), len(lines))
+	}
+
+	// every beacon once, the reconnects carry on where the log was dropped
+	for i, pos := range positions {
+		if want := start.Add(time.Duration(i) * 30 * time.Second); !pos.Timestamp.Equal(want) {
+			t.Errorf("position %d: got %s, want %s", i, pos.Timestamp, want)
+		}
+	}
+	if n := server.Connections(); n != 3 {
+		t.Errorf("got %d connections, want 3", n)
+	}
+	if n := aprs.CurrentStats().Rejected - before.Rejected; n != 2 {
+		t.Errorf("got %d rejected lines, want the 2 garbage lines", n)
+	}
+
+	if !database {
+		t.Log("DATABASE_URL not set, starts and landings not checked")
+		return
+	}
+	flights := startlist_db.GetFlights("")
+	if len(flights) != 1 {
+		t.Fatalf("got %d flights, want 1", len(flights))
+	}
+	f := flights[0]
+	if f.OgnId != "FLRDDA5BA" || f.Start != start.Add(2*time.Minute).Unix() || f.Landing != start.Add(8*time.Minute).Unix() {
+		t.Errorf("got %+v", f)
+	}
+}
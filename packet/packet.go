@@ -0,0 +1,266 @@
+package packet
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type PacketType int
+
+const (
+	UNKNOWN PacketType = iota
+	LOCATION
+	STATUS
+)
+
+func (t PacketType) String() string {
+	switch t {
+	case LOCATION:
+		return "Location"
+	case STATUS:
+		return "Status"
+	default:
+		return "Unknown"
+	}
+}
+
+// Packet mirrors the subset of libfap's fap_packet_t used by OGN beacons.
+type Packet struct {
+	Type       PacketType
+	OrigPacket string
+
+	Header string
+	Body   string
+
+	SrcCallsign string
+	DstCallsign string
+	Path        []string
+
+	Latitude      float64
+	Longitude     float64
+	PosResolution float64 // in meters
+	Altitude      float64 // in meters
+	Course        uint    // in degrees
+	Speed         float64 // in km/h
+
+	SymbolTable byte
+	SymbolCode  byte
+
+	Comment   string
+	Status    string
+	Timestamp time.Time
+}
+
+var (
+	altitude_matcher  = regexp.MustCompile(`/A=(-\d{5}|\d{6})`)
+	precision_matcher = regexp.MustCompile(`!W(\d)(\d)!`)
+	course_matcher    = regexp.MustCompile(`^(\d{3})/(\d{3})`)
+)
+
+const (
+	feet_to_meters = 0.3048
+	knots_to_kmh   = 1.852
+)
+
+// example: FLRDDA5BA>APRS,qAS,LFMX:/165829h4415.41N/00600.03E'342/049/A=005524 !W26! id21400EA9 -019fpm +0.0rot 55.2dB 0e -9.9kHz gps3x6
+func Parse(line string) (*Packet, error) {
+	return ParseAt(line, time.Now())
+}
+
+// ParseAt completes the partial beacon timestamps with the date closest to now,
+// eg. the time a logged line was received at.
+func ParseAt(line string, now time.Time) (*Packet, error) {
+	line = strings.TrimRight(line, "\r\n")
+
+	sep := strings.Index(line, ":")
+	if sep < 0 {
+		return nil, errors.New("packet: missing header/body separator")
+	}
+
+	p := &Packet{OrigPacket: line, Header: line[:sep], Body: line[sep+1:]}
+	if err := p.parseHeader(); err != nil {
+		return nil, err
+	}
+	if len(p.Body) == 0 {
+		return nil, errors.New("packet: empty body")
+	}
+
+	var err error
+	switch p.Body[0] {
+	case '/', '@':
+		err = p.parseLocation(p.Body[1:], true, now)
+	case '!', '=':
+		err = p.parseLocation(p.Body[1:], false, now)
+	case '>':
+		err = p.parseStatus(p.Body[1:], now)
+	default:
+		err = fmt.Errorf("packet: unsupported packet type %q", p.Body[0])
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *Packet) parseHeader() error {
+	gt := strings.Index(p.Header, ">")
+	if gt < 1 {
+		return errors.New("packet: missing source callsign")
+	}
+
+	p.SrcCallsign = p.Header[:gt]
+	items := strings.Split(p.Header[gt+1:], ",")
+	if items[0] == "" {
+		return errors.New("packet: missing destination callsign")
+	}
+
+	p.DstCallsign = items[0]
+	p.Path = items[1:]
+	return nil
+}
+
+func (p *Packet) parseLocation(s string, timestamped bool, now time.Time) error {
+	p.Type = LOCATION
+
+	if timestamped {
+		if len(s) < 7 {
+			return errors.New("packet: timestamp too short")
+		}
+		t, err := parseTimestamp(s[:7], now)
+		if err != nil {
+			return err
+		}
+		p.Timestamp = t
+		s = s[7:]
+	}
+
+	// DDMM.mmN/DDDMM.mmE'
+	if len(s) < 19 {
+		return errors.New("packet: position too short")
+	}
+
+	var err error
+	if p.Latitude, err = parseCoordinate(s[0:8], 2, 'N', 'S'); err != nil {
+		return err
+	}
+	if p.Longitude, err = parseCoordinate(s[9:18], 3, 'E', 'W'); err != nil {
+		return err
+	}
+	p.SymbolTable = s[8]
+	p.SymbolCode = s[18]
+	p.PosResolution = 18.52
+
+	rest := s[19:]
+	if m := course_matcher.FindStringSubmatch(rest); m != nil {
+		course, _ := strconv.ParseUint(m[1], 10, 32)
+		speed, _ := strconv.ParseFloat(m[2], 64)
+		p.Course = uint(course)
+		p.Speed = speed * knots_to_kmh
+		rest = rest[len(m[0]):]
+	}
+
+	if m := altitude_matcher.FindStringSubmatch(rest); m != nil {
+		alt, _ := strconv.ParseFloat(m[1], 64)
+		p.Altitude = alt * feet_to_meters
+		rest = strings.Replace(rest, m[0], "", 1)
+	}
+
+	// !Wab! adds a third decimal to the minutes of latitude (a) and longitude (b)
+	if m := precision_matcher.FindStringSubmatch(rest); m != nil {
+		p.Latitude = addPrecision(p.Latitude, m[1])
+		p.Longitude = addPrecision(p.Longitude, m[2])
+		p.PosResolution = 1.852
+		rest = strings.Replace(rest, m[0], "", 1)
+	}
+
+	p.Comment = strings.TrimSpace(rest)
+	return nil
+}
+
+func (p *Packet) parseStatus(s string, now time.Time) error {
+	p.Type = STATUS
+
+	if len(s) >= 7 && strings.IndexByte("hz/", s[6]) >= 0 {
+		if t, err := parseTimestamp(s[:7], now); err == nil {
+			p.Timestamp = t
+			s = s[7:]
+		}
+	}
+
+	p.Status = strings.TrimSpace(s)
+	return nil
+}
+
+// HHMMSSh or DDHHMMz/DDHHMM/ in UTC. The missing date parts are taken from now,
+// so that 23:59:59 received shortly after midnight stays on the previous day.
+func parseTimestamp(s string, now time.Time) (time.Time, error) {
+	var a, b, c int
+	if _, err := fmt.Sscanf(s[:6], "%2d%2d%2d", &a, &b, &c); err != nil {
+		return time.Time{}, fmt.Errorf("packet: invalid timestamp %q", s)
+	}
+
+	now = now.UTC()
+	switch s[6] {
+	case 'h':
+		if a > 23 || b > 59 || c > 59 {
+			return time.Time{}, fmt.Errorf("packet: invalid timestamp %q", s)
+		}
+		t := time.Date(now.Year(), now.Month(), now.Day(), a, b, c, 0, time.UTC)
+		if t.Sub(now) > 12*time.Hour {
+			t = t.AddDate(0, 0, -1)
+		} else if now.Sub(t) > 12*time.Hour {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	case 'z', '/':
+		if a < 1 || a > 31 || b > 23 || c > 59 {
+			return time.Time{}, fmt.Errorf("packet: invalid timestamp %q", s)
+		}
+		t := time.Date(now.Year(), now.Month(), a, b, c, 0, 0, time.UTC)
+		if t.Sub(now) > 15*24*time.Hour {
+			t = time.Date(now.Year(), now.Month()-1, a, b, c, 0, 0, time.UTC)
+		} else if now.Sub(t) > 15*24*time.Hour {
+			t = time.Date(now.Year(), now.Month()+1, a, b, c, 0, 0, time.UTC)
+		}
+		return t, nil
+	default:
+		return time.Time{}, fmt.Errorf("packet: invalid timestamp %q", s)
+	}
+}
+
+func parseCoordinate(s string, deg_len int, pos byte, neg byte) (float64, error) {
+	deg, err := strconv.ParseUint(s[:deg_len], 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("packet: invalid coordinate %q", s)
+	}
+	min, err := strconv.ParseFloat(s[deg_len:len(s)-1], 64)
+	if err != nil || !(min >= 0 && min < 60) {
+		return 0, fmt.Errorf("packet: invalid coordinate %q", s)
+	}
+
+	c := float64(deg) + min/60
+	if (deg_len == 2 && c > 90) || c > 180 {
+		return 0, fmt.Errorf("packet: invalid coordinate %q", s)
+	}
+	switch s[len(s)-1] {
+	case pos:
+		return c, nil
+	case neg:
+		return -c, nil
+	default:
+		return 0, fmt.Errorf("packet: invalid coordinate %q", s)
+	}
+}
+
+func addPrecision(c float64, digit string) float64 {
+	d, _ := strconv.ParseFloat(digit, 64)
+	if c < 0 {
+		return c - d/1000/60
+	}
+	return c + d/1000/60
+}
@@ -0,0 +1,168 @@
+package packet
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2015, 8, 28, 17, 0, 0, 0, time.UTC)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		line      string
+		typ       PacketType
+		src       string
+		dst       string
+		lat       float64
+		lon       float64
+		alt       float64
+		course    uint
+		speed     float64
+		comment   string
+		timestamp time.Time
+	}{
+		{
+			line:      `FLRDDA5BA>APRS,qAS,LFMX:/165829h4415.41N/00600.03E'342/049/A=005524 !W26! id21400EA9 -019fpm +0.0rot 55.2dB 0e -9.9kHz gps3x6`,
+			typ:       LOCATION,
+			src:       "FLRDDA5BA",
+			dst:       "APRS",
+			lat:       44.256867,
+			lon:       6.0006,
+			alt:       1683.7152,
+			course:    342,
+			speed:     90.748,
+			comment:   "id21400EA9 -019fpm +0.0rot 55.2dB 0e -9.9kHz gps3x6",
+			timestamp: time.Date(2015, 8, 28, 16, 58, 29, 0, time.UTC),
+		},
+		{
+			line:      `OGN2FD00F>OGNTRK,qAS,LZHL:/093213h4848.78N/01708.32E'000/000/A=000515 !W12! id072FD00F -019fpm +0.0rot FL003.12 32.5dB 0e -0.8kHz gps3x5`,
+			typ:       LOCATION,
+			src:       "OGN2FD00F",
+			dst:       "OGNTRK",
+			lat:       48.813017,
+			lon:       17.138700,
+			alt:       156.972,
+			comment:   "id072FD00F -019fpm +0.0rot FL003.12 32.5dB 0e -0.8kHz gps3x5",
+			timestamp: time.Date(2015, 8, 28, 9, 32, 13, 0, time.UTC),
+		},
+		{
+			line:      `ICA4B0E3A>OGADSB,qAS,Letzi:/072707h4726.62N\00823.86E^117/391/A=019700 !W20! id254B0E3A FL195.28 +2048fpm`,
+			typ:       LOCATION,
+			src:       "ICA4B0E3A",
+			dst:       "OGADSB",
+			lat:       47.443700,
+			lon:       8.397667,
+			alt:       6004.56,
+			course:    117,
+			speed:     724.132,
+			comment:   "id254B0E3A FL195.28 +2048fpm",
+			timestamp: time.Date(2015, 8, 28, 7, 27, 7, 0, time.UTC),
+		},
+		{
+			line:      `LSPH>OGNSDR,TCPIP*,qAC,GLIDERN2:/132201h4657.02NI00722.57E&/A=001690`,
+			typ:       LOCATION,
+			src:       "LSPH",
+			dst:       "OGNSDR",
+			lat:       46.950333,
+			lon:       7.376167,
+			alt:       515.112,
+			timestamp: time.Date(2015, 8, 28, 13, 22, 1, 0, time.UTC),
+		},
+		{
+			line:      `LSPH>OGNSDR,TCPIP*,qAC,GLIDERN2:>132201h v0.2.8.RPI-GPU CPU:0.5 RAM:700.3/970.5MB NTP:0.3ms/-5.8ppm +51.0C`,
+			typ:       STATUS,
+			src:       "LSPH",
+			dst:       "OGNSDR",
+			timestamp: time.Date(2015, 8, 28, 13, 22, 1, 0, time.UTC),
+		},
+		{
+			// shortly before midnight, received after it
+			line:      `FLRDDA5BA>APRS,qAS,LFMX:/235959h4415.41S/00600.03W'342/049/A=005524`,
+			typ:       LOCATION,
+			src:       "FLRDDA5BA",
+			dst:       "APRS",
+			lat:       -44.256833,
+			lon:       -6.0005,
+			alt:       1683.7152,
+			course:    342,
+			speed:     90.748,
+			timestamp: time.Date(2015, 8, 27, 23, 59, 59, 0, time.UTC),
+		},
+	}
+
+	for _, test := range tests {
+		received := now
+		if test.timestamp.Day() != now.Day() {
+			received = time.Date(2015, 8, 28, 0, 0, 10, 0, time.UTC)
+		}
+
+		p, err := ParseAt(test.line, received)
+		if err != nil {
+			t.Errorf("%s: %s", test.line, err)
+			continue
+		}
+		if p.Type != test.typ || p.SrcCallsign != test.src || p.DstCallsign != test.dst {
+			t.Errorf("%s: got %s %s>%s", test.line, p.Type, p.SrcCallsign, p.DstCallsign)
+		}
+		if math.Abs(p.Latitude-test.lat) > 1e-6 || math.Abs(p.Longitude-test.lon) > 1e-6 {
+			t.Errorf("%s: got position %f,%f, want %f,%f", test.line, p.Latitude, p.Longitude, test.lat, test.lon)
+		}
+		if math.Abs(p.Altitude-test.alt) > 1e-6 || p.Course != test.course || math.Abs(p.Speed-test.speed) > 1e-6 {
+			t.Errorf("%s: got %fm %d° %fkm/h", test.line, p.Altitude, p.Course, p.Speed)
+		}
+		if test.typ == LOCATION && p.Comment != test.comment {
+			t.Errorf("%s: got comment %q, want %q", test.line, p.Comment, test.comment)
+		}
+		if !p.Timestamp.Equal(test.timestamp) {
+			t.Errorf("%s: got timestamp %s, want %s", test.line, p.Timestamp, test.timestamp)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	lines := []string{
+		``,
+		`FLRDDA5BA>APRS,qAS,LFMX`,
+		`>APRS:/165829h4415.41N/00600.03E'`,
+		`FLRDDA5BA>:/165829h4415.41N/00600.03E'`,
+		`FLRDDA5BA>APRS,qAS,LFMX:`,
+		`FLRDDA5BA>APRS,qAS,LFMX:}165829h4415.41N/00600.03E'`,
+		`FLRDDA5BA>APRS,qAS,LFMX:/16582`,
+		`FLRDDA5BA>APRS,qAS,LFMX:/255829h4415.41N/00600.03E'`,
+		`FLRDDA5BA>APRS,qAS,LFMX:/166029h4415.41N/00600.03E'`,
+		`FLRDDA5BA>APRS,qAS,LFMX:/322359z4415.41N/00600.03E'`,
+		`FLRDDA5BA>APRS,qAS,LFMX:/165829x4415.41N/00600.03E'`,
+		`FLRDDA5BA>APRS,qAS,LFMX:/165829h9115.41N/00600.03E'`,
+		`FLRDDA5BA>APRS,qAS,LFMX:/165829h4465.41N/00600.03E'`,
+		`FLRDDA5BA>APRS,qAS,LFMX:/165829h4415.41N/18100.03E'`,
+		`FLRDDA5BA>APRS,qAS,LFMX:/165829h4415.41X/00600.03E'`,
+		`FLRDDA5BA>APRS,qAS,LFMX:/165829h4415.41N/00600.03E`,
+	}
+
+	for _, line := range lines {
+		if p, err := ParseAt(line, now); err == nil {
+			t.Errorf("%q: expected an error, got %+v", line, p)
+		}
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	f.Add(`FLRDDA5BA>APRS,qAS,LFMX:/165829h4415.41N/00600.03E'342/049/A=005524 !W26! id21400EA9 -019fpm +0.0rot 55.2dB 0e -9.9kHz gps3x6`)
+	f.Add(`ICA4B0E3A>OGADSB,qAS,Letzi:/072707h4726.62N\00823.86E^117/391/A=019700 !W20! id254B0E3A FL195.28 +2048fpm`)
+	f.Add(`LSPH>OGNSDR,TCPIP*,qAC,GLIDERN2:>132201h v0.2.8.RPI-GPU CPU:0.5 RAM:700.3/970.5MB`)
+	f.Add(`LSPH>OGNSDR,TCPIP*,qAC,GLIDERN2:=4657.02NI00722.57E&/A=001690`)
+
+	f.Fuzz(func(t *testing.T, line string) {
+		p, err := ParseAt(line, now)
+		if err != nil {
+			return
+		}
+		if p.Latitude < -90 || p.Latitude > 90 || p.Longitude < -180 || p.Longitude > 180 {
+			t.Errorf("%q: position %f,%f out of range", line, p.Latitude, p.Longitude)
+		}
+		if !p.Timestamp.IsZero() && (p.Timestamp.Sub(now) > 32*24*time.Hour || now.Sub(p.Timestamp) > 32*24*time.Hour) {
+			t.Errorf("%q: timestamp %s too far from %s", line, p.Timestamp, now)
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package receivers
+
+import (
+	"fmt"
+	"github.com/masone/ogn/alert"
+	"github.com/masone/ogn/clock"
+	"github.com/masone/ogn/config"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Monitor alerts when a critical receiver stops beaconing or relaying
+// aircraft during flying hours, and again once it has recovered.
+type Monitor struct {
+	Critical      []string
+	BeaconTimeout time.Duration
+	RelayTimeout  time.Duration
+	FlyingFrom    time.Duration // since midnight, airfield time
+	FlyingTo      time.Duration
+	Location      *time.Location
+	Alerter       alert.Alerter
+
+	clock   clock.Clock
+	started time.Time
+	alerted map[string]string
+}
+
+const check_interval = 1 * time.Minute
+
+// NewMonitorFromEnv returns nil if CRITICAL_RECEIVERS is not set.
+func NewMonitorFromEnv() (*Monitor, error) {
+	critical := config.List(os.Getenv("CRITICAL_RECEIVERS"))
+	if len(critical) == 0 {
+		return nil, nil
+	}
+
+	m := &Monitor{
+		Critical:      critical,
+		BeaconTimeout: minutes("RECEIVER_TIMEOUT", 15),
+		RelayTimeout:  minutes("RECEIVER_RELAY_TIMEOUT", 60),
+		FlyingFrom:    8 * time.Hour,
+		FlyingTo:      21 * time.Hour,
+		Location:      config.Location(),
+		Alerter:       alert.FromEnv(),
+	}
+
+	if hours := os.Getenv("FLYING_HOURS"); hours != "" {
+		var fh, fm, th, tm int
+		if _, err := fmt.Sscanf(hours, "%d:%d-%d:%d", &fh, &fm, &th, &tm); err != nil {
+			return nil, fmt.Errorf("receivers: invalid FLYING_HOURS %q, expected 08:00-21:00", hours)
+		}
+		m.FlyingFrom = time.Duration(fh)*time.Hour + time.Duration(fm)*time.Minute
+		m.FlyingTo = time.Duration(th)*time.Hour + time.Duration(tm)*time.Minute
+	}
+	return m, nil
+}
+
+func minutes(key string, fallback int) time.Duration {
+	m, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || m <= 0 {
+		m = fallback
+	}
+	return time.Duration(m) * time.Minute
+}
+
+// Start checks the receivers every minute of the given clock.
+func (m *Monitor) Start(c clock.Clock) {
+	m.clock = c
+	m.alerted = make(map[string]string)
+	c.AfterFunc(check_interval, m.run)
+}
+
+func (m *Monitor) run() {
+	m.check(m.clock.Now())
+	m.clock.AfterFunc(check_interval, m.run)
+}
+
+func (m *Monitor) check(now time.Time) {
+	if m.started.IsZero() {
+		m.started = now
+	}
+	if !m.flying(now) {
+		return
+	}
+
+	for _, name := range m.Critical {
+		r, _ := Get(name)
+		problem := m.problem(r, now)
+
+		switch {
+		case problem != "" && m.alerted[name] == "":
+			m.send(fmt.Sprintf("Receiver %s %s", name, problem), r)
+		case problem == "" && m.alerted[name] != "":
+			m.send(fmt.Sprintf("Receiver %s recovered", name), r)
+		}
+		m.alerted[name] = problem
+	}
+}
+
+func (m *Monitor) problem(r Receiver, now time.Time) string {
+	seen := latest(r.LastSeen, m.started)
+	relayed := latest(r.LastRelayed, m.started)
+
+	if now.Sub(seen) > m.BeaconTimeout {
+		return fmt.Sprintf("offline for %s", now.Sub(seen).Truncate(time.Minute))
+	}
+	if now.Sub(relayed) > m.RelayTimeout {
+		return fmt.Sprintf("relayed no aircraft for %s", now.Sub(relayed).Truncate(time.Minute))
+	}
+	return ""
+}
+
+func (m *Monitor) flying(now time.Time) bool {
+	local := now.In(m.Location)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, m.Location)
+	since := local.Sub(midnight)
+	return since >= m.FlyingFrom && since < m.FlyingTo
+}
+
+func (m *Monitor) send(subject string, r Receiver) {
+	message := fmt.Sprintf("%s\nLast beacon: %s\nLast relayed aircraft: %s",
+		subject, format(r.LastSeen, m.Location), format(r.LastRelayed, m.Location))
+	if err := m.Alerter.Alert(subject, message); err != nil {
+		log.Println(err)
+	}
+}
+
+func latest(a time.Time, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func format(t time.Time, loc *time.Location) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.In(loc).Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,92 @@
+package receivers
+
+import (
+	"github.com/masone/ogn/clock"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordingAlerter struct {
+	subjects []string
+}
+
+func (a *recordingAlerter) Alert(subject string, message string) error {
+	a.subjects = append(a.subjects, subject)
+	return nil
+}
+
+// 12:00 UTC, within the flying hours
+var noon = time.Date(2015, 8, 28, 12, 0, 0, 0, time.UTC)
+
+func newTestMonitor() (*Monitor, *recordingAlerter) {
+	receivers = make(ReceiverList)
+	a := &recordingAlerter{}
+	m := &Monitor{
+		Critical:      []string{"LSPH"},
+		BeaconTimeout: 15 * time.Minute,
+		RelayTimeout:  60 * time.Minute,
+		FlyingFrom:    8 * time.Hour,
+		FlyingTo:      21 * time.Hour,
+		Location:      time.UTC,
+		Alerter:       a,
+		started:       noon.Add(-3 * time.Hour),
+		alerted:       make(map[string]string),
+	}
+	return m, a
+}
+
+func seen(name string, beacon time.Time, relayed time.Time) {
+	r := receiver(name)
+	r.LastSeen = beacon
+	r.LastRelayed = relayed
+}
+
+func TestMonitorCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		now     time.Time
+		beacon  time.Time
+		relayed time.Time
+		want    string
+	}{
+		{"fine", noon, noon.Add(-time.Minute), noon.Add(-10 * time.Minute), ""},
+		{"silent beacons", noon, noon.Add(-20 * time.Minute), noon.Add(-20 * time.Minute), "Receiver LSPH offline for 20m0s"},
+		{"missing relays", noon, noon.Add(-time.Minute), noon.Add(-2 * time.Hour), "Receiver LSPH relayed no aircraft for 2h0m0s"},
+		{"never seen", noon, time.Time{}, time.Time{}, "Receiver LSPH offline for 3h0m0s"},
+		{"before flying hours", noon.Add(-5 * time.Hour), time.Time{}, time.Time{}, ""},
+		{"after flying hours", noon.Add(9 * time.Hour), noon, noon, ""},
+	}
+
+	for _, test := range tests {
+		m, a := newTestMonitor()
+		seen("LSPH", test.beacon, test.relayed)
+		m.check(test.now)
+
+		got := strings.Join(a.subjects, "; ")
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+// An outage is reported once when it starts and once when it is over.
+func TestMonitorOutage(t *testing.T) {
+	m, a := newTestMonitor()
+	m.started = time.Time{}
+
+	c := clock.NewSimulated(noon)
+	seen("LSPH", noon, noon)
+	m.Start(c)
+
+	for i := 1; i <= 30; i++ {
+		c.Set(noon.Add(time.Duration(i) * time.Minute))
+	}
+	seen("LSPH", c.Now(), c.Now())
+	c.Set(c.Now().Add(time.Minute))
+
+	want := []string{"Receiver LSPH offline for 16m0s", "Receiver LSPH recovered"}
+	if strings.Join(a.subjects, "; ") != strings.Join(want, "; ") {
+		t.Errorf("got %q, want %q", a.subjects, want)
+	}
+}
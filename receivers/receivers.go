@@ -0,0 +1,191 @@
+package receivers
+
+import (
+	"fmt"
+	"github.com/kellydunn/golang-geo"
+	"github.com/masone/ogn/config"
+	"github.com/masone/ogn/packet"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Receiver struct {
+	Name      string
+	Latitude  float64
+	Longitude float64
+	Altitude  float64 // in meters
+	Distance  float64 // to the airfield, in kilometers
+	LastSeen  time.Time
+	Status
+
+	Relayed     uint64 // aircraft positions
+	LastRelayed time.Time
+}
+
+type Status struct {
+	Version       string
+	Platform      string
+	CpuLoad       float64
+	RamFree       float64 // in MB
+	RamTotal      float64 // in MB
+	NtpOffset     float64 // in ms
+	NtpDrift      float64 // in ppm
+	Temperature   float64 // in °C
+	RfCorrection  float64 // in ppm
+	RfNoise       float64 // in dB
+	RfSensitivity float64 // in dB@10km
+}
+
+type ReceiverList map[string]*Receiver
+
+var (
+	receivers  = make(ReceiverList)
+	mutex      sync.RWMutex
+	home_point *geo.Point
+)
+
+var (
+	version_matcher     = regexp.MustCompile(`^v(\d+\.\d+\.\d+)\.?(\S*)$`)
+	cpu_matcher         = regexp.MustCompile(`^CPU:(\d+(?:\.\d+)?)$`)
+	ram_matcher         = regexp.MustCompile(`^RAM:(\d+(?:\.\d+)?)/(\d+(?:\.\d+)?)MB$`)
+	ntp_matcher         = regexp.MustCompile(`^NTP:([+-]?\d+(?:\.\d+)?)ms/([+-]?\d+(?:\.\d+)?)ppm$`)
+	temperature_matcher = regexp.MustCompile(`^([+-]\d+(?:\.\d+)?)C$`)
+	rf_matcher          = regexp.MustCompile(`^RF:([+-]\d+)([+-]\d+(?:\.\d+)?)ppm/([+-]\d+(?:\.\d+)?)dB(?:/([+-]\d+(?:\.\d+)?)dB@10km)?`)
+)
+
+func Init() {
+	af := config.GetAirfield()
+	home_point = geo.NewPoint(af.Lat, af.Lng)
+}
+
+// Process handles receiver position and status beacons:
+// LSPH>OGNSDR,TCPIP*,qAC,GLIDERN2:/132201h4657.02NI00722.57E&/A=001690
+// LSPH>OGNSDR,TCPIP*,qAC,GLIDERN2:>132201h v0.2.8.RPI-GPU CPU:0.5 RAM:700.3/970.5MB NTP:0.3ms/-5.8ppm +51.0C RF:+54-1.1ppm/+1.14dB/+4.4dB@10km[15087]
+func Process(p *packet.Packet) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	r := receiver(p.SrcCallsign)
+	// beacons without timestamp (! and = positions) don't tell when they were sent
+	if p.Timestamp.After(r.LastSeen) {
+		r.LastSeen = p.Timestamp
+	}
+
+	switch p.Type {
+	case packet.LOCATION:
+		if r.Latitude == 0 && r.Longitude == 0 && home_point != nil {
+			defer log.Printf("Receiver %s seen %.1fkm from the airfield\n", p.SrcCallsign, home_point.GreatCircleDistance(geo.NewPoint(p.Latitude, p.Longitude)))
+		}
+		r.Latitude = p.Latitude
+		r.Longitude = p.Longitude
+		r.Altitude = p.Altitude
+		if home_point != nil {
+			r.Distance = home_point.GreatCircleDistance(geo.NewPoint(p.Latitude, p.Longitude))
+		}
+	case packet.STATUS:
+		status, err := ParseStatus(p.Status)
+		if err != nil {
+			return err
+		}
+		r.Status = status
+	}
+	return nil
+}
+
+// Relayed records that the receiver relayed an aircraft position.
+func Relayed(name string, t time.Time) {
+	if name == "" {
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	r := receiver(name)
+	r.Relayed++
+	if t.After(r.LastRelayed) {
+		r.LastRelayed = t
+	}
+}
+
+func Get(name string) (Receiver, bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	r, ok := receivers[name]
+	if !ok {
+		return Receiver{}, false
+	}
+	return *r, true
+}
+
+// All returns the known receivers, nearest to the airfield first.
+func All() []Receiver {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	list := make([]Receiver, 0, len(receivers))
+	for _, r := range receivers {
+		list = append(list, *r)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Distance < list[j].Distance
+	})
+	return list
+}
+
+func ParseStatus(s string) (Status, error) {
+	var status Status
+	for _, item := range strings.Fields(s) {
+		if m := version_matcher.FindStringSubmatch(item); m != nil {
+			status.Version = m[1]
+			status.Platform = m[2]
+		} else if m := cpu_matcher.FindStringSubmatch(item); m != nil {
+			status.CpuLoad = parseFloat(m[1])
+		} else if m := ram_matcher.FindStringSubmatch(item); m != nil {
+			status.RamFree = parseFloat(m[1])
+			status.RamTotal = parseFloat(m[2])
+		} else if m := ntp_matcher.FindStringSubmatch(item); m != nil {
+			status.NtpOffset = parseFloat(m[1])
+			status.NtpDrift = parseFloat(m[2])
+		} else if m := temperature_matcher.FindStringSubmatch(item); m != nil {
+			status.Temperature = parseFloat(m[1])
+		} else if m := rf_matcher.FindStringSubmatch(item); m != nil {
+			status.RfCorrection = parseFloat(m[1]) + parseFloat(m[2])
+			status.RfNoise = parseFloat(m[3])
+			if m[4] != "" {
+				status.RfSensitivity = parseFloat(m[4])
+			}
+		}
+		// other tokens (eg. 3/3Acfts[1h]) are not used
+	}
+
+	if status.Version == "" {
+		return status, fmt.Errorf("receivers: unknown status format %q", s)
+	}
+	return status, nil
+}
+
+func (r Receiver) String() string {
+	return fmt.Sprintf("%s %.1fkm v%s, last seen %s, relayed %d (last %s)",
+		r.Name, r.Distance, r.Version, r.LastSeen.Format(time.RFC3339), r.Relayed, r.LastRelayed.Format(time.RFC3339))
+}
+
+func receiver(name string) *Receiver {
+	r, ok := receivers[name]
+	if !ok {
+		r = &Receiver{Name: name}
+		receivers[name] = r
+	}
+	return r
+}
+
+func parseFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
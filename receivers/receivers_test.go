@@ -0,0 +1,68 @@
+package receivers
+
+import (
+	"github.com/masone/ogn/packet"
+	"testing"
+	"time"
+)
+
+func TestParseStatus(t *testing.T) {
+	tests := []struct {
+		status string
+		want   Status
+	}{
+		{
+			status: "v0.2.8.RPI-GPU CPU:0.5 RAM:700.3/970.5MB NTP:0.3ms/-5.8ppm +51.0C RF:+54-1.1ppm/+1.14dB/+4.4dB@10km[15087]",
+			want: Status{Version: "0.2.8", Platform: "RPI-GPU", CpuLoad: 0.5, RamFree: 700.3, RamTotal: 970.5,
+				NtpOffset: 0.3, NtpDrift: -5.8, Temperature: 51, RfCorrection: 52.9, RfNoise: 1.14, RfSensitivity: 4.4},
+		},
+		{
+			// older versions without platform, temperature and sensitivity
+			status: "v0.2.4 CPU:1.2 RAM:200.0/500.0MB NTP:1.5ms/+3.0ppm RF:+40+0.5ppm/-0.3dB 3/3Acfts[1h]",
+			want: Status{Version: "0.2.4", CpuLoad: 1.2, RamFree: 200, RamTotal: 500,
+				NtpOffset: 1.5, NtpDrift: 3, RfCorrection: 40.5, RfNoise: -0.3},
+		},
+	}
+
+	for _, test := range tests {
+		s, err := ParseStatus(test.status)
+		if err != nil {
+			t.Errorf("%s: %v", test.status, err)
+			continue
+		}
+		if s != test.want {
+			t.Errorf("%s:\ngot  %+v\nwant %+v", test.status, s, test.want)
+		}
+	}
+
+	if _, err := ParseStatus("CPU:0.5 RAM:700.3/970.5MB"); err == nil {
+		t.Error("want an error without version")
+	}
+}
+
+// Beacons without timestamp keep the receiver seen.
+func TestProcessLastSeen(t *testing.T) {
+	receivers = make(ReceiverList)
+	now := time.Date(2015, 8, 28, 13, 22, 1, 0, time.UTC)
+
+	for _, line := range []string{
+		"LSPH>OGNSDR,TCPIP*,qAC,GLIDERN2:/132201h4657.02NI00722.57E&/A=001690",
+		"LSPH>OGNSDR,TCPIP*,qAC,GLIDERN2:=4657.02NI00722.57E&/A=001690",
+	} {
+		p, err := packet.ParseAt(line, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Process(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, ok := Get("LSPH")
+	if !ok || !r.LastSeen.Equal(now) {
+		t.Errorf("got %+v, want last seen %s", r, now)
+	}
+	if r.Altitude < 515 || r.Altitude > 516 {
+		t.Errorf("got altitude %f", r.Altitude)
+	}
+}
@@ -3,92 +3,133 @@ package startlist
 import (
 	"fmt"
 	"github.com/kellydunn/golang-geo"
+	"github.com/masone/ogn/clock"
+	"github.com/masone/ogn/config"
+	"github.com/masone/ogn/ddb"
 	"github.com/masone/ogn/startlist_db"
 	"math"
-	"os"
-	"strconv"
 	"time"
 )
 
 var (
-	home_point             *geo.Point
-	home_elevation         float64
-	elevation_threshold    float64 = 20  // in meters
-	distance_threshold     float64 = 0.5 // in kilometers
-	winch_launch_threshold float64 = 200 // in meters
-	tow_threshold          float64 = 20  // in meters
+	home_location *time.Location
+	airfields     = []config.Airfield{config.DefaultAirfield}
 )
 
+var clk clock.Clock = clock.Real{}
+
+// categories which may tow a glider, aircraft of unknown category are never taken for a tow plane
+var tow_categories = []string{string(ddb.TOW_PLANE), string(ddb.POWERED)}
+
+// SetClock replaces the wall clock, eg. with a simulated one for replays.
+func SetClock(c clock.Clock) {
+	clk = c
+}
+
 func Init() {
-	home_lat, _ := strconv.ParseFloat(os.Getenv("AF_LAT"), 64)
-	home_lng, _ := strconv.ParseFloat(os.Getenv("AF_LNG"), 64)
-	home_point = geo.NewPoint(home_lat, home_lng)
-	home_elevation, _ = strconv.ParseFloat(os.Getenv("AF_ELEVATION"), 64)
+	airfields = config.Airfields()
+	home_location = config.Location()
 
-	startlist_db.Init()
+	first := airfields[0]
+	startlist_db.Init(home_location, first.PositionWindow, first.QueryWindow)
 	fmt.Println("")
 }
 
-func ProcessEntry(ft time.Time, id string, cs string, lat float64, lon float64, alt float64, climb_rate float64) {
+// PrintOnly limits the printed flights to one airfield, all if the name is empty.
+func PrintOnly(name string) error {
+	if name != "" && airfield_named(name).Name != name {
+		return fmt.Errorf("startlist: unknown airfield %s", name)
+	}
+	startlist_db.PrintOnly(name)
+	return nil
+}
+
+// PrintStartlist prints the recorded flights of one airfield, of all if the name is empty.
+func PrintStartlist(name string) {
+	fmt.Println("")
+	startlist_db.PrintFlights(startlist_db.GetFlights(name))
+}
+
+// unknown devices are not in the DDB, helicopters are not part of the startlist
+func ProcessEntry(ft time.Time, id string, cs string, lat float64, lon float64, alt float64, climb_rate float64, turn_rate float64, receiver string, unknown bool, category ddb.Category) {
+	if category == ddb.HELICOPTER {
+		return
+	}
+
 	//plane := geo.NewPoint(lat, lon)
-	//fmt.Printf("    %s - %fkm away - %fm\n", cs, home_point.GreatCircleDistance(plane), alt)
+	//fmt.Printf("    %s - %fkm away - %fm\n", cs, geo.NewPoint(af.Lat, af.Lng).GreatCircleDistance(plane), alt)
 	t := packetTime(ft)
 	var pos string
-	nc := near_coordinates(lat, lon)
-	ng := near_ground(alt)
+	af := nearest_airfield(lat, lon)
+	nc := near_coordinates(af, lat, lon)
+	ng := near_ground(af, alt)
 
 	if nc && ng {
 		pos = "gnd"
-		handleOnGround(t, id, cs)
+		handleOnGround(t, id, cs, unknown, category, af)
 	} else if !nc && !ng {
 		pos = "air"
-		handleAirborne(t, id, cs)
+		handleAirborne(t, id, cs, unknown, category)
 	} else {
 		// Position is not 100% clear. Store, but don't qualify.
 		// Prevents detecting false starts/landings.
 		// The Flarm altitude is sometimes off (eg. when the device boots up).
 		pos = ""
 	}
-	startlist_db.InsertPosition(t, id, cs, pos, climb_rate, alt, lat, lon)
+	startlist_db.InsertPosition(t, id, cs, pos, climb_rate, turn_rate, alt, lat, lon, receiver, af.Name)
 }
 
-func handleOnGround(t time.Time, id string, cs string) {
-	lastPosition := startlist_db.GetLastPosition(id, t)
+// The landing may be at another airfield than the start.
+func handleOnGround(t time.Time, id string, cs string, unknown bool, category ddb.Category, af config.Airfield) {
+	lastPosition, _ := startlist_db.GetLastPosition(id, t)
 
 	if lastPosition == "air" {
 		//fmt.Printf("*** %s landed %s at %s\n", cs, t, id)
-		startlist_db.InsertLanding(t, id, cs)
+		startlist_db.InsertLanding(t, id, cs, unknown, string(category), af.Name)
 	} else {
 		//fmt.Printf("    %s still on ground %s\n", cs, id)
 	}
 }
 
-func handleAirborne(t time.Time, id string, cs string) {
-	lastPosition := startlist_db.GetLastPosition(id, t)
+func handleAirborne(t time.Time, id string, cs string, unknown bool, category ddb.Category) {
+	// started from the airfield the aircraft was last seen on ground
+	lastPosition, name := startlist_db.GetLastPosition(id, t)
 
 	if lastPosition == "gnd" {
 		//fmt.Printf("*** %s started (%s) at %s\n", cs, t, id)
-		startlist_db.InsertStart(t, id, cs)
-
-		go func() {
-			delay := 20 * time.Second
-			delayed := t.Add(delay)
-			time.Sleep(delay)
-			detectLaunchType(id, t, delayed, cs)
-		}() // TODO: sync
+		af := airfield_named(name)
+		startlist_db.InsertStart(t, id, cs, unknown, string(category), af.Name)
+
+		delay := af.LaunchDelay
+		clk.AfterFunc(delay, func() {
+			detectLaunchType(id, t, t.Add(delay), cs, category, af)
+		}) // TODO: sync
 	} else {
 		//fmt.Printf("    %s still airborne %s\n", cs, id)
 	}
 }
 
-func detectLaunchType(id string, t time.Time, dt time.Time, cs string) string {
+func detectLaunchType(id string, t time.Time, dt time.Time, cs string, category ddb.Category, af config.Airfield) string {
+	switch category {
+	case ddb.MOTOR_GLIDER, ddb.POWERED, ddb.TOW_PLANE:
+		// tow planes are marked by the glider they towed
+		startlist_db.UpdateFlightDetails(id, t, "S", 0)
+		return "S"
+	}
+
 	max := startlist_db.GetRecentMaxAlt(id, t)
-	diff := math.Abs(max - home_elevation)
+	diff := math.Abs(max - af.Elevation)
+	climb := startlist_db.GetRecentMaxClimbRate(id, t)
 
+	// The winch pulls a glider up much steeper than a tow plane or an engine.
+	// The height gain is a fallback for devices not sending their vertical speed.
 	var lt string
-	if detectTow(id, t, dt, cs) {
+	if climb >= af.WinchClimbThreshold {
+		//fmt.Printf("    %s started W (%s), climb rate %f\n", cs, t, climb)
+		lt = "W"
+	} else if detectTow(id, t, dt, cs, af) {
 		lt = "A"
-	} else if diff > winch_launch_threshold {
+	} else if diff > af.WinchLaunchThreshold {
 		//fmt.Printf("    %s started W (%s), height gain %f\n", cs, t, diff)
 		lt = "W"
 	} else {
@@ -100,47 +141,72 @@ func detectLaunchType(id string, t time.Time, dt time.Time, cs string) string {
 	return lt
 }
 
-func detectTow(id string, t time.Time, dt time.Time, cs string) bool {
-	last_id := startlist_db.GetRecentParallelStart(id, dt)
-	if last_id != "" {
+func detectTow(id string, t time.Time, dt time.Time, cs string, af config.Airfield) bool {
+	tow, ok := startlist_db.GetRecentParallelStart(id, dt, tow_categories, af.Name)
+	if ok {
 		alts1 := startlist_db.GetRecentAvgAltitude(id, dt)
-		alts2 := startlist_db.GetRecentAvgAltitude(last_id, dt)
+		alts2 := startlist_db.GetRecentAvgAltitude(tow.OgnId, dt)
 
 		diff := math.Abs(alts2 - alts1)
 
-		//fmt.Printf("    %s started in parallel with %s - h diff %f\n", id, last_id, diff)
-		if diff < tow_threshold {
+		//fmt.Printf("    %s started in parallel with %s - h diff %f\n", id, tow.OgnId, diff)
+		if diff < af.TowThreshold {
+			startlist_db.LinkTow(id, t, tow)
 			return true
 		}
 	}
 	return false
 }
 
-func near_coordinates(lat float64, lon float64) bool {
+// nearest_airfield is the one whose geofence contains the position, else the closest one.
+func nearest_airfield(lat float64, lon float64) config.Airfield {
+	plane := geo.NewPoint(lat, lon)
+	nearest := airfields[0]
+	min := -1.0
+	for _, af := range airfields {
+		if af.Fence != nil && af.Fence.Contains(lat, lon) {
+			return af
+		}
+		d := geo.NewPoint(af.Lat, af.Lng).GreatCircleDistance(plane)
+		if min < 0 || d < min {
+			nearest, min = af, d
+		}
+	}
+	return nearest
+}
+
+func airfield_named(name string) config.Airfield {
+	for _, af := range airfields {
+		if af.Name == name {
+			return af
+		}
+	}
+	return airfields[0]
+}
+
+func near_coordinates(af config.Airfield, lat float64, lon float64) bool {
+	if af.Fence != nil {
+		return af.Fence.Contains(lat, lon)
+	}
+
 	plane := geo.NewPoint(lat, lon)
-	if home_point.GreatCircleDistance(plane) <= distance_threshold {
+	if geo.NewPoint(af.Lat, af.Lng).GreatCircleDistance(plane) <= af.DistanceThreshold {
 		return true
 	} else {
 		return false
 	}
 }
 
-func near_ground(a float64) bool {
-	if a > home_elevation-elevation_threshold && a < home_elevation+elevation_threshold {
+func near_ground(af config.Airfield, a float64) bool {
+	if a > af.Elevation-af.ElevationThreshold && a < af.Elevation+af.ElevationThreshold {
 		return true
 	} else {
 		return false
 	}
 }
 
-// The Flarm timestamp uses a Hours/Minutes/Seconds format. The date is not passed explicitely.
-// libfap-go messes up when converting this to a time, resulting in the correct time for different dates.
+// The Flarm timestamp uses a Hours/Minutes/Seconds format in UTC. The date is not passed explicitely,
+// the packet parser infers it from the reception time. Flights are recorded in the airfield's time zone.
 func packetTime(t time.Time) time.Time {
-	now := time.Now()
-	hour, min, sec := t.Clock()
-	day := now.Day()
-	month := now.Month()
-	year := now.Year()
-
-	return time.Date(year, month, day, hour, min, sec, 0, time.Local)
+	return t.In(home_location)
 }
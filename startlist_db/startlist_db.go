@@ -11,13 +11,17 @@ type Flight struct {
 	Id               uint   `gorm:"primary_key"`
 	OgnId            string `validate:"presence"`
 	Callsign         string `sql:"size(12)"`
-	LaunchType       string `sql:"size(1)"`
+	Airfield         string `sql:"size(32);default:''"` // of the start
+	LandingAirfield  string `sql:"size(32);default:''"`
+	LaunchType       string `sql:"size(1)"` // W(inch), A(erotow), S(elf start) or T(ow) for the tow plane
+	Category         string `sql:"size(10)"`
 	Start            int64
 	FormattedStart   string
 	Landing          int64
 	FormattedLanding string
 	Duration         int64
-	TowFlight        int64 `sql:"references flights(id)"`
+	TowFlight        int64 `sql:"references flights(id)"` // the other flight of an aerotow
+	Unknown          bool  // not in the DDB, the callsign is a placeholder
 }
 type Position struct {
 	Id            uint  `gorm:"primary_key"`
@@ -27,14 +31,34 @@ type Position struct {
 	Callsign      string `sql:"size(12)"`
 	Position      string `validate:"presence" sql:"size(3)"`
 	ClimbRate     float64
+	TurnRate      float64
 	Altitude      float64 `validate:"presence"`
 	Lat           float64 `validate:"presence"`
 	Lon           float64 `validate:"presence"`
+	Receiver      string  `sql:"size(9)"`
+	Airfield      string  `sql:"size(32);default:''"` // nearest to the position
 }
 
-var db gorm.DB
+var (
+	db       gorm.DB
+	location *time.Location = time.Local
+)
+
+var (
+	position_window = 5 * time.Minute
+	query_window    = 30 * time.Second
+)
+
+// prints the flights of all airfields if empty
+var only_airfield string
+
+// The positions of the last window tell whether a position is a start or landing,
+// the ones around a start within the query window its launch type.
+func Init(loc *time.Location, last_position time.Duration, query time.Duration) {
+	location = loc
+	position_window = last_position
+	query_window = query
 
-func Init() {
 	var err error
 	db, err = gorm.Open("postgres", os.Getenv("DATABASE_URL"))
 	checkErr(err)
@@ -46,8 +70,9 @@ func Init() {
 	fmt.Println("")
 }
 
-func InsertStart(t time.Time, id string, cs string) uint {
-	flight := initializeFlight(id, cs)
+func InsertStart(t time.Time, id string, cs string, unknown bool, category string, airfield string) uint {
+	flight := initializeFlight(id, cs, unknown, category)
+	flight.Airfield = airfield
 	flight.Start = t.Unix()
 	flight.FormattedStart = t.String()
 
@@ -55,19 +80,25 @@ func InsertStart(t time.Time, id string, cs string) uint {
 	return flight.Id
 }
 
-func InsertLanding(t time.Time, id string, cs string) {
+func InsertLanding(t time.Time, id string, cs string, unknown bool, category string, airfield string) {
 	var flight Flight
 	var results []Flight
 	db.Where("ogn_id = ? AND landing = 0", id).Last(&results)
 
 	if len(results) > 0 {
 		flight = results[0]
+		// the device was added to the DDB during the flight
+		if flight.Unknown && !unknown {
+			flight.Callsign = cs
+			flight.Unknown = false
+		}
 	} else {
-		flight = initializeFlight(id, cs)
+		flight = initializeFlight(id, cs, unknown, category)
 	}
 
 	flight.Landing = t.Unix()
 	flight.FormattedLanding = t.String()
+	flight.LandingAirfield = airfield
 	flight.Duration = flight.Landing - flight.Start
 
 	if flight.Callsign != " HB-KDF (  )" {
@@ -78,7 +109,7 @@ func InsertLanding(t time.Time, id string, cs string) {
 	checkErr(query.Error)
 }
 
-func InsertPosition(t time.Time, id string, cs string, pos string, cr float64, alt float64, lat float64, lon float64) {
+func InsertPosition(t time.Time, id string, cs string, pos string, cr float64, tr float64, alt float64, lat float64, lon float64, rcv string, airfield string) {
 	position := &Position{
 		OgnId:         id,
 		Callsign:      cs,
@@ -86,9 +117,12 @@ func InsertPosition(t time.Time, id string, cs string, pos string, cr float64, a
 		FormattedTime: t.String(),
 		Position:      pos,
 		ClimbRate:     cr,
+		TurnRate:      tr,
 		Altitude:      alt,
 		Lat:           lat,
 		Lon:           lon,
+		Receiver:      rcv,
+		Airfield:      airfield,
 	}
 	query := db.Save(position)
 	checkErr(query.Error)
@@ -99,7 +133,8 @@ func UpdateFlightDetails(id string, t time.Time, lt string, tfId int64) {
 	query := db.Where("ogn_id = ? AND start = ?", id, t.Unix()).Last(&flight)
 	checkErr(query.Error)
 
-	if lt != "" {
+	// a glider may have marked the flight as its tow already
+	if lt != "" && flight.LaunchType == "" {
 		flight.LaunchType = lt
 	}
 	if tfId > 0 {
@@ -110,27 +145,28 @@ func UpdateFlightDetails(id string, t time.Time, lt string, tfId int64) {
 	db.Save(&flight)
 }
 
-func GetLastPosition(id string, t time.Time) (p string) {
+// GetLastPosition returns the last qualified position and the airfield it was nearest to.
+func GetLastPosition(id string, t time.Time) (p string, airfield string) {
 	var results []Position
 
-	past := t.Add(-5 * time.Minute)
+	past := t.Add(-position_window)
 	query := db.
 		Where("ogn_id = ? AND time < ? AND time > ? AND position != ?", id, t.Unix(), past.Unix(), "").
 		Last(&results)
 
 	checkErr(query.Error)
 	if len(results) > 0 {
-		return results[0].Position
+		return results[0].Position, results[0].Airfield
 	} else {
-		return ""
+		return "", ""
 	}
 }
 
 func GetRecentMaxAlt(id string, t time.Time) float64 {
 	var results []Position
 
-	past := t.Add(-30 * time.Second)
-	future := t.Add(30 * time.Second)
+	past := t.Add(-query_window)
+	future := t.Add(query_window)
 
 	query := db.
 		Select("MAX(altitude) as altitude").
@@ -145,28 +181,62 @@ func GetRecentMaxAlt(id string, t time.Time) float64 {
 	}
 }
 
-func GetRecentParallelStart(id string, t time.Time) string {
+func GetRecentMaxClimbRate(id string, t time.Time) float64 {
+	var results []Position
+
+	past := t.Add(-query_window)
+	future := t.Add(query_window)
+
+	query := db.
+		Select("MAX(climb_rate) as climb_rate").
+		Where("ogn_id = ? AND time < ? AND time > ?", id, future.Unix(), past.Unix()).
+		Find(&results)
+
+	checkErr(query.Error)
+	if len(results) > 0 {
+		return results[0].ClimbRate
+	} else {
+		return 0.0
+	}
+}
+
+// GetRecentParallelStart finds a flight of the given categories started around the same time at the airfield.
+func GetRecentParallelStart(id string, t time.Time, categories []string, airfield string) (Flight, bool) {
 	var results []Flight
 
-	past := t.Add(-30 * time.Second)
-	future := t.Add(30 * time.Second)
+	past := t.Add(-query_window)
+	future := t.Add(query_window)
 	query := db.
-		Select("ogn_id").
-		Where("ogn_id != ? AND start < ? AND start > ?", id, future.Unix(), past.Unix()).
+		Where("ogn_id != ? AND start < ? AND start > ? AND category IN (?) AND airfield = ?", id, future.Unix(), past.Unix(), categories, airfield).
 		Last(&results)
 
 	checkErr(query.Error)
 	if len(results) > 0 {
-		return results[0].OgnId
+		return results[0], true
 	} else {
-		return ""
+		return Flight{}, false
 	}
 }
 
+// LinkTow records the aerotow of the glider started at t by the tow flight.
+func LinkTow(id string, t time.Time, tow Flight) {
+	var flight Flight
+	query := db.Where("ogn_id = ? AND start = ?", id, t.Unix()).Last(&flight)
+	checkErr(query.Error)
+
+	flight.LaunchType = "A"
+	flight.TowFlight = int64(tow.Id)
+	db.Save(&flight)
+
+	tow.LaunchType = "T"
+	tow.TowFlight = int64(flight.Id)
+	db.Save(&tow)
+}
+
 func GetRecentAvgAltitude(id string, t time.Time) float64 {
 	var results []Position
 
-	past := t.Add(-30 * time.Second)
+	past := t.Add(-query_window)
 	query := db.
 		Select("AVG(altitude) as altitude").
 		Where("ogn_id = ? AND time < ? AND time > ?", id, t.Unix(), past.Unix()).
@@ -181,25 +251,60 @@ func GetRecentAvgAltitude(id string, t time.Time) float64 {
 	}
 }
 
+// GetFlights returns the flights started or landed at the airfield ordered by start,
+// the flights of all airfields if the name is empty.
+func GetFlights(airfield string) []Flight {
+	var results []Flight
+
+	query := db.Order("start, landing")
+	if airfield != "" {
+		query = query.Where("airfield = ? OR landing_airfield = ?", airfield, airfield)
+	}
+	query = query.Find(&results)
+
+	checkErr(query.Error)
+	return results
+}
+
+// PrintOnly limits the printed flights to the ones started or landed at the airfield.
+func PrintOnly(airfield string) {
+	only_airfield = airfield
+}
+
+func PrintFlights(flights []Flight) {
+	for _, f := range flights {
+		printFlight(f)
+	}
+}
+
 func printFlight(f Flight) {
+	if only_airfield != "" && f.Airfield != only_airfield && f.LandingAirfield != only_airfield {
+		return
+	}
 	duration, _ := time.ParseDuration(fmt.Sprintf("%ds", f.Duration))
 
+	// named when tracking multiple airfields
+	if f.Airfield != "" || f.LandingAirfield != "" {
+		fmt.Printf("%s > %s | ", f.Airfield, f.LandingAirfield)
+	}
 	fmt.Printf("%s | %s | %02d:%02d | %02d:%02d | %d:%02d \n",
 		f.Callsign,
 		f.LaunchType,
-		time.Unix(f.Start, 0).Hour(),
-		time.Unix(f.Start, 0).Minute(),
-		time.Unix(f.Landing, 0).Hour(),
-		time.Unix(f.Landing, 0).Minute(),
+		time.Unix(f.Start, 0).In(location).Hour(),
+		time.Unix(f.Start, 0).In(location).Minute(),
+		time.Unix(f.Landing, 0).In(location).Hour(),
+		time.Unix(f.Landing, 0).In(location).Minute(),
 		int(duration.Hours()),
 		int(duration.Minutes()),
 	)
 }
 
-func initializeFlight(id string, cs string) Flight {
+func initializeFlight(id string, cs string, unknown bool, category string) Flight {
 	return Flight{
 		OgnId:    id,
 		Callsign: cs,
+		Unknown:  unknown,
+		Category: category,
 	}
 }
 